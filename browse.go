@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wojciech-malota-wojcik/legacy/payload"
+)
+
+// maxTextPreview is the maximum number of bytes of text file displayed in preview
+const maxTextPreview = 1 << 20
+
+// sessionCookie is the name of cookie authenticating browser once one-time token has been used
+const sessionCookie = "legacy-session"
+
+// shmDir is the memory-backed file system preferred for decrypted payloads, so they never reach the disk
+const shmDir = "/dev/shm"
+
+// browseDir creates private temporary directory where payloads are decrypted before they are served
+func browseDir() (string, error) {
+	if info, err := os.Stat(shmDir); err == nil && info.IsDir() {
+		if dir, err := ioutil.TempDir(shmDir, "legacy-browse-"); err == nil {
+			return dir, nil
+		}
+	}
+	return ioutil.TempDir("", "legacy-browse-")
+}
+
+// guardBrowseDir wipes dir and terminates the process once ctx is canceled by signal, because prompts for secrets
+// can't be interrupted. Returned function hands handling of the signal over to the caller, after it returns,
+// the caller is responsible for wiping dir.
+func guardBrowseDir(ctx context.Context, dir string) func() {
+	var mu sync.Mutex
+	handedOver := false
+	go func() {
+		<-ctx.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		if handedOver {
+			return
+		}
+		fmt.Fprintln(os.Stderr)
+		if err := wipe(dir); err != nil {
+			log.Fatalf("Wiping decrypted data failed, remove %s manually: %s", dir, err)
+		}
+		log.Fatal("Interrupted, decrypted data have been wiped")
+	}()
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		handedOver = true
+	}
+}
+
+// wipe overwrites decrypted files with zeros and removes the directory
+func wipe(dir string) error {
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// directories restored from archive might be read-only
+			return os.Chmod(file, 0o700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if err := os.Chmod(file, 0o600); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(file, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(f, payload.ZeroReader{}, info.Size())
+		if err2 := f.Sync(); err == nil {
+			err = err2
+		}
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		return err
+	})
+	if err2 := os.RemoveAll(dir); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// browse serves decrypted payloads stored in dir over HTTP server listening on loopback interface,
+// it runs until ctx is canceled
+func browse(ctx context.Context, dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No payload has been decrypted, there is nothing to browse")
+		return nil
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	b, err := newBrowser(dir, l.Addr().String())
+	if err != nil {
+		l.Close()
+		return err
+	}
+	server := &http.Server{Handler: b, ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(l)
+	}()
+
+	fmt.Fprintln(os.Stderr, "Decrypted payloads are available in your web browser, open this link, it works only once:")
+	fmt.Fprintf(os.Stderr, "  http://%s/?token=%s\n", l.Addr(), b.token)
+	fmt.Fprintln(os.Stderr, "Press Ctrl+C to stop the server, decrypted data will be wiped")
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// browser is the HTTP handler serving decrypted payloads, the first request must present one-time token
+// which is exchanged for session cookie
+type browser struct {
+	root    string
+	host    string
+	token   string
+	session string
+
+	mu        sync.Mutex
+	tokenUsed bool
+}
+
+func newBrowser(root, host string) (*browser, error) {
+	token, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+	session, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+	return &browser{root: root, host: host, token: token, session: session}, nil
+}
+
+func randomHex() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ServeHTTP serves request
+func (b *browser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	// host is checked to protect against DNS rebinding
+	if r.Host != b.host {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		b.login(w, r, token)
+		return
+	}
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(b.session)) != 1 {
+		http.Error(w, "forbidden, open the link printed by the executable", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/":
+		http.Redirect(w, r, "/files/", http.StatusFound)
+	case strings.HasPrefix(r.URL.Path, "/files/"):
+		b.serveFiles(w, r, strings.TrimPrefix(r.URL.Path, "/files/"))
+	case strings.HasPrefix(r.URL.Path, "/raw/"):
+		b.serveRaw(w, r, strings.TrimPrefix(r.URL.Path, "/raw/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// login exchanges one-time token for session cookie
+func (b *browser) login(w http.ResponseWriter, r *http.Request, token string) {
+	b.mu.Lock()
+	valid := !b.tokenUsed && subtle.ConstantTimeCompare([]byte(token), []byte(b.token)) == 1
+	if valid {
+		b.tokenUsed = true
+	}
+	b.mu.Unlock()
+
+	if !valid {
+		http.Error(w, "token is invalid or has been used already", http.StatusForbidden)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    b.session,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/files/", http.StatusFound)
+}
+
+// resolve returns path of file inside root, symlinks are never followed so nothing outside of root is served
+func (b *browser) resolve(name string) (string, os.FileInfo, error) {
+	name = path.Clean("/" + name)
+	file := b.root
+	info, err := os.Lstat(file)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(name, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		file = filepath.Join(file, part)
+		info, err = os.Lstat(file)
+		if err != nil {
+			return "", nil, err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", nil, errors.New("symlinks are not followed")
+		}
+	}
+	return file, info, nil
+}
+
+type listingEntry struct {
+	Name string
+	Path string
+	Dir  bool
+	Size int64
+	Link string
+}
+
+type page struct {
+	Path    string
+	Parent  string
+	Entries []listingEntry
+	Preview string
+	Text    string
+	Raw     string
+	Size    int64
+}
+
+func (b *browser) serveFiles(w http.ResponseWriter, r *http.Request, name string) {
+	file, info, err := b.resolve(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	name = strings.Trim(path.Clean("/"+name), "/")
+	p := page{Path: "/" + name}
+	if name != "" {
+		p.Parent = strings.TrimPrefix(path.Dir("/"+name), "/")
+	}
+
+	if info.IsDir() {
+		infos, err := ioutil.ReadDir(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, i := range infos {
+			e := listingEntry{Name: i.Name(), Path: path.Join(name, i.Name()), Dir: i.IsDir(), Size: i.Size()}
+			if i.Mode()&os.ModeSymlink != 0 {
+				e.Link, _ = os.Readlink(filepath.Join(file, i.Name()))
+			}
+			p.Entries = append(p.Entries, e)
+		}
+		sort.Slice(p.Entries, func(i, j int) bool {
+			if p.Entries[i].Dir != p.Entries[j].Dir {
+				return p.Entries[i].Dir
+			}
+			return p.Entries[i].Name < p.Entries[j].Name
+		})
+		b.render(w, p)
+		return
+	}
+
+	p.Raw = name
+	p.Size = info.Size()
+	p.Preview = previewKind(name)
+	if p.Preview == "text" {
+		f, err := os.Open(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		text, err := ioutil.ReadAll(io.LimitReader(f, maxTextPreview))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		p.Text = string(text)
+	}
+	b.render(w, p)
+}
+
+func (b *browser) serveRaw(w http.ResponseWriter, r *http.Request, name string) {
+	file, info, err := b.resolve(name)
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	// only types which are previewed are rendered by the browser, anything else, html especially, is downloaded
+	contentType := "application/octet-stream"
+	switch previewKind(name) {
+	case "text":
+		contentType = "text/plain; charset=utf-8"
+	case "image", "pdf":
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	w.Header().Set("Content-Type", contentType)
+	if previewKind(name) != "pdf" {
+		// PDF viewer of the browser doesn't work in sandbox
+		w.Header().Set("Content-Security-Policy", "sandbox")
+	}
+	if r.URL.Query().Get("download") != "" || contentType == "application/octet-stream" {
+		w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(path.Base(name)))
+	}
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// previewKind returns kind of preview displayed for file
+func previewKind(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".txt", ".md", ".csv", ".json", ".yaml", ".yml", ".log", ".xml":
+		return "text"
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+		return "image"
+	case ".pdf":
+		return "pdf"
+	default:
+		return ""
+	}
+}
+
+func (b *browser) render(w http.ResponseWriter, p page) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src 'self'; frame-src 'self'; object-src 'self'; style-src 'unsafe-inline'")
+	if err := pTplPage.Execute(w, p); err != nil {
+		fmt.Fprintf(os.Stderr, "Rendering page failed: %s\n", err)
+	}
+}
+
+var pTplPage = template.Must(template.New("").Funcs(template.FuncMap{
+	"escape": func(p string) string {
+		parts := strings.Split(p, "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		return strings.Join(parts, "/")
+	},
+}).Parse(tplPage))
+
+const tplPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Legacy: {{ .Path }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+td { padding: 0.2em 1em; }
+pre { white-space: pre-wrap; background: #f4f4f4; padding: 1em; }
+img { max-width: 100%; }
+iframe { width: 100%; height: 80vh; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>{{ .Path }}</h1>
+{{ if ne .Path "/" }}<p><a href="/files/{{ escape .Parent }}">Up</a></p>{{ end }}
+{{ if .Raw }}
+<p>{{ .Size }} bytes, <a href="/raw/{{ escape .Raw }}?download=1">download</a></p>
+{{ if eq .Preview "text" }}<pre>{{ .Text }}</pre>
+{{ else if eq .Preview "image" }}<img src="/raw/{{ escape .Raw }}" alt="{{ .Raw }}">
+{{ else if eq .Preview "pdf" }}<iframe src="/raw/{{ escape .Raw }}"></iframe>
+{{ else }}<p>Preview is not available for this file.</p>{{ end }}
+{{ else }}
+<table>
+{{ range .Entries }}<tr>
+{{ if .Link }}<td>{{ .Name }} &rarr; {{ .Link }}</td><td>symlink</td><td></td>
+{{ else if .Dir }}<td><a href="/files/{{ escape .Path }}">{{ .Name }}/</a></td><td>directory</td><td></td>
+{{ else }}<td><a href="/files/{{ escape .Path }}">{{ .Name }}</a></td><td>{{ .Size }} bytes</td><td><a href="/raw/{{ escape .Path }}?download=1">download</a></td>{{ end }}
+</tr>{{ end }}
+</table>
+{{ end }}
+</body>
+</html>
+`
@@ -2,17 +2,19 @@ package main
 
 import (
 	"context"
-	"github.com/wojciech-malota-wojcik/legacy/util"
 	"log"
 
 	"github.com/wojciech-malota-wojcik/build"
 	"github.com/wojciech-malota-wojcik/ioc"
 	me "github.com/wojciech-malota-wojcik/legacy/build"
+	"github.com/wojciech-malota-wojcik/legacy/util"
 )
 
 func main() {
 	util.WorkingDir(1)
-	ctx := context.Background()
+	ctx, cancel := util.SignalContext(context.Background())
+	defer cancel()
+
 	c := ioc.New()
 	c.Singleton(func() context.Context {
 		return ctx
@@ -0,0 +1,152 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/payload"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// containers are the files encrypted payloads are written to before the executable is built
+type containers struct {
+	payload     *os.File
+	offset      int64
+	shards      []*os.File
+	shardOffset int64
+}
+
+// createContainers creates container of payloads and, if any payload is sharded, shard file of each successor
+func createContainers(cfg config.Config) (*containers, error) {
+	out, err := os.OpenFile(payloadFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if err != nil {
+		return nil, err
+	}
+	c := &containers{payload: out}
+	for _, p := range cfg.Payloads {
+		if !p.Sharded {
+			continue
+		}
+		for i := range cfg.Successors {
+			f, err := os.OpenFile(shardContainer(i), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o444)
+			if err != nil {
+				_ = c.close()
+				return nil, err
+			}
+			c.shards = append(c.shards, f)
+		}
+		break
+	}
+	return c, nil
+}
+
+// writePayload encrypts data of payload and writes the result to container of payloads
+func (c *containers) writePayload(cfg config.Config, p config.Payload, key []byte, data *types.Data) error {
+	data.Offset = c.offset
+	if cfg.ExternalPayload {
+		data.PayloadFile = filepath.Base(externalPayloadFile(cfg))
+	}
+	if _, err := encryptData(cfg, p, key, data, c.payload); err != nil {
+		return err
+	}
+	c.offset += data.Size
+	return nil
+}
+
+// writeShards encrypts data of payload and splits the result into shards written to shard files of successors
+func (c *containers) writeShards(cfg config.Config, p config.Payload, key []byte, data *types.Data) error {
+	tmp, err := ioutil.TempFile("./parts", "sharded-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := encryptData(cfg, p, key, data, tmp); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return c.store(cfg, p, tmp, data)
+}
+
+// store writes encrypted stream of data read from r to container of payloads or splits it into shards if payload is sharded,
+// location of the stream is set in data
+func (c *containers) store(cfg config.Config, p config.Payload, r io.Reader, data *types.Data) error {
+	if !p.Sharded {
+		if _, err := io.CopyN(c.payload, r, data.Size); err != nil {
+			return err
+		}
+		data.Offset = c.offset
+		if cfg.ExternalPayload {
+			data.PayloadFile = filepath.Base(externalPayloadFile(cfg))
+		}
+		c.offset += data.Size
+		return nil
+	}
+
+	shardSize, hashes, err := payload.WriteShards(r, data.Size, p.RequiredToDecrypt, c.shards, c.shardOffset)
+	if err != nil {
+		return err
+	}
+	data.Offset = c.shardOffset
+	data.ShardSize = shardSize
+	data.ShardHashes = hashes
+	data.ShardFiles = make([]string, 0, len(cfg.Successors))
+	for i := range cfg.Successors {
+		data.ShardFiles = append(data.ShardFiles, filepath.Base(shardFile(cfg, i)))
+	}
+	c.shardOffset += shardSize
+	fmt.Printf("Payload %s split into %d shards of %d bytes, any %d of them are enough to reconstruct it\n",
+		p.Name, len(c.shards), shardSize, p.RequiredToDecrypt)
+	return nil
+}
+
+func (c *containers) close() error {
+	err := c.payload.Close()
+	for _, f := range c.shards {
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// moveShards moves shard files of successors next to the executable, if they were generated
+func moveShards(cfg config.Config) error {
+	for i, s := range cfg.Successors {
+		if _, err := os.Stat(shardContainer(i)); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if err := os.Rename(shardContainer(i), shardFile(cfg, i)); err != nil {
+			return err
+		}
+		fmt.Printf("Shard file of successor %s stored in %s, give it to the successor\n", successorLabel(s, i), shardFile(cfg, i))
+	}
+	return nil
+}
+
+// shardContainer returns path of the file where shards of successor are stored before the executable is built
+func shardContainer(successor int) string {
+	return fmt.Sprintf("./parts/shard%d.bin", successor)
+}
+
+// shardFile returns path of shard file of successor stored next to the executable
+func shardFile(cfg config.Config, successor int) string {
+	return fmt.Sprintf("bin/%s.%d.shard", cfg.ExeName, successor)
+}
+
+func successorLabel(s config.Successor, i int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return fmt.Sprintf("%d", i)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/wojciech-malota-wojcik/build"
 )
@@ -18,8 +19,18 @@ func runCmd(cmd *exec.Cmd) error {
 	return cmd.Run()
 }
 
+// goBuildPkg builds package, paths, build ID and VCS state are not stored in the binary so the same sources produce the same binary
 func goBuildPkg(ctx context.Context, pkg, out string) error {
-	return runCmd(exec.CommandContext(ctx, "go", "build", "-o", out, "./"+pkg))
+	return runCmd(exec.CommandContext(ctx, "go", "build", "-trimpath", "-buildvcs=false", "-ldflags=-buildid=", "-o", out, "./"+pkg))
+}
+
+// goVersion returns version of Go toolchain used to build packages
+func goVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
 }
 
 func lint(ctx context.Context, deps build.DepsFunc) error {
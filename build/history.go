@@ -0,0 +1,166 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/payload"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// lineageVersion is the version of payload data whose encrypted stream is kept in history directory
+type lineageVersion struct {
+	// Data contains fields describing encrypted stream of the version and its metadata
+	Data types.Data
+
+	// Hash is the hash of plaintext, it is used to deduplicate versions
+	Hash []byte
+
+	// File is the name of the file in history directory of payload storing encrypted stream
+	File string
+}
+
+// historyDir returns directory where encrypted versions of payload are kept
+func historyDir(cfg config.Config, p config.Payload) string {
+	return filepath.Join(cfg.HistoryDir, p.Name)
+}
+
+// resetHistory moves versions of payloads encrypted using data keys of previous build aside, to the directory named
+// after time of the build, next to the history directory, so they are never removed without owner's decision
+func resetHistory(cfg config.Config) error {
+	if _, err := os.Stat(cfg.HistoryDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	previousDir := filepath.Clean(cfg.HistoryDir) + "-previous"
+	if err := os.MkdirAll(previousDir, 0o700); err != nil {
+		return err
+	}
+	now, err := buildTime()
+	if err != nil {
+		return err
+	}
+	name := now.UTC().Format("20060102T150405Z")
+	previous := filepath.Join(previousDir, name)
+	// reproducible builds share the same build time, so suffix is added to keep history of each of them
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(previous); os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return err
+		}
+		previous = filepath.Join(previousDir, fmt.Sprintf("%s-%d", name, i))
+	}
+	if err := os.Rename(cfg.HistoryDir, previous); err != nil {
+		return err
+	}
+	fmt.Printf("Versions of payloads created by previous build can't be decrypted by new keys, they are moved to %s\n", previous)
+	return nil
+}
+
+// addVersion encrypts current data of payload using data key and adds them to the history,
+// if the same content has been stored already its encrypted stream is reused
+func addVersion(cfg config.Config, p config.Payload, dataKey []byte, lp *lineagePayload) error {
+	dir := historyDir(cfg, p)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".version-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var data types.Data
+	metadata, err := encryptData(cfg, p, dataKey, &data, f)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if n := len(lp.Versions); n > 0 && bytes.Equal(lp.Versions[n-1].Hash, metadata.Manifest.Hash) {
+		fmt.Printf("Payload %s has not changed since version created at %s, new version is not added\n",
+			p.Name, versionTime(lp.Versions[n-1].Data, dataKey))
+		return nil
+	}
+	for _, v := range lp.Versions {
+		if !bytes.Equal(v.Hash, metadata.Manifest.Hash) {
+			continue
+		}
+
+		// content is the same as in older version, its encrypted stream is reused with new metadata
+		vMetadata, _, err := payload.OpenMetadata(v.Data, dataKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Payload %s is the same as version created at %s, its encrypted data are reused\n",
+			p.Name, vMetadata.Time.Local().Format(time.RFC1123))
+		vMetadata.Time = metadata.Time
+		vMetadata.Note = metadata.Note
+		version := v.Data
+		version.Metadata, err = payload.SealMetadata(dataKey, vMetadata)
+		if err != nil {
+			return err
+		}
+		lp.Versions = append(lp.Versions, lineageVersion{Data: version, Hash: v.Hash, File: v.File})
+		fmt.Printf("Version %d of payload %s added to history\n", len(lp.Versions), p.Name)
+		return nil
+	}
+
+	file := fmt.Sprintf("%x.bin", data.Hash)
+	if err := os.Rename(f.Name(), filepath.Join(dir, file)); err != nil {
+		return err
+	}
+	lp.Versions = append(lp.Versions, lineageVersion{Data: data, Hash: metadata.Manifest.Hash, File: file})
+	fmt.Printf("Version %d of payload %s added to history\n", len(lp.Versions), p.Name)
+	return nil
+}
+
+// storeHistory stores encrypted streams of all the versions of payload in containers, each stream is stored once,
+// data is set to the newest version and the earlier ones are stored in its history
+func storeHistory(cfg config.Config, p config.Payload, c *containers, lp *lineagePayload, data *types.Data) error {
+	stored := map[string]types.Data{}
+	for i := len(lp.Versions) - 1; i >= 0; i-- {
+		v := lp.Versions[i]
+		located, ok := stored[v.File]
+		if !ok {
+			located = v.Data
+			f, err := os.Open(filepath.Join(historyDir(cfg, p), v.File))
+			if err != nil {
+				return err
+			}
+			err = c.store(cfg, p, f, &located)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			stored[v.File] = located
+		}
+		version := located
+		version.Metadata = v.Data.Metadata
+		if i == len(lp.Versions)-1 {
+			*data = payload.WithStream(*data, version)
+			continue
+		}
+		data.History = append(data.History, payload.WithStream(types.Data{}, version))
+	}
+	return nil
+}
+
+func versionTime(data types.Data, dataKey []byte) string {
+	metadata, ok, err := payload.OpenMetadata(data, dataKey)
+	if err != nil || !ok {
+		return "unknown time"
+	}
+	return metadata.Time.Local().Format(time.RFC1123)
+}
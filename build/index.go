@@ -2,11 +2,17 @@ package build
 
 // Commands is a definition of commands available in build system
 var Commands = map[string]interface{}{
-	"tools/build":   buildMe,
-	"dev/goimports": goImports,
-	"dev/lint":      lint,
-	"dev/test":      test,
-	"dev/build":     buildLegacyDev,
-	"build":         buildLegacyProd,
-	"public-key":    printPublicKey,
+	"tools/build":      buildMe,
+	"dev/goimports":    goImports,
+	"dev/lint":         lint,
+	"dev/test":         test,
+	"dev/build":        buildLegacyDev,
+	"dev/update-data":  updateLegacyDev,
+	"dev/owner-key":    ownerKeyDev,
+	"dev/verify-build": verifyBuildDev,
+	"build":            buildLegacyProd,
+	"update-data":      updateLegacyProd,
+	"owner-key":        ownerKeyProd,
+	"verify-build":     verifyBuildProd,
+	"public-key":       printPublicKey,
 }
@@ -5,22 +5,23 @@ import (
 	"context"
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"os"
 	"strings"
-	"text/template"
 
 	"github.com/go-piv/piv-go/piv"
 	"github.com/wojciech-malota-wojcik/build"
 	"github.com/wojciech-malota-wojcik/ioc"
 	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/payload"
 	"github.com/wojciech-malota-wojcik/legacy/types"
 	"github.com/wojciech-malota-wojcik/legacy/util"
 )
@@ -69,160 +70,602 @@ func buildLegacyDev(c *ioc.Container, deps build.DepsFunc) {
 	deps(buildLegacy)
 }
 
-func generateLegacy(cfg config.Config) error {
-	knownParts(cfg)
-
-	if err := os.RemoveAll("./parts"); err != nil && !os.IsNotExist(err) {
+func generateLegacy(ctx context.Context, cfg config.Config) error {
+	required, err := requiredSuccessors(cfg)
+	if err != nil {
 		return err
 	}
-	if err := os.Mkdir("./parts", 0o755); err != nil {
-		return err
+	for _, p := range cfg.Payloads {
+		fmt.Printf("Payload %s:\n", p.Name)
+		knownParts(len(cfg.Successors), p.RequiredToDecrypt)
 	}
 
-	seed := make([]byte, config.SeedSize)
-	if _, err := rand.Read(seed); err != nil {
+	ownerKey, err := loadOwnerKey(cfg)
+	if err != nil {
 		return err
 	}
 
-	// encrypt data using symmetric key
-
-	key := util.BuildPrivateKey(seed)
-	rawData, err := ioutil.ReadFile(cfg.DataFile)
+	passphrases, err := readPassphrases(cfg)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		for _, p := range passphrases {
+			p.Release()
+		}
+	}()
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
+	secrets := [][]byte{}
+	for i := range cfg.Successors {
+		if p, ok := passphrases[i]; ok {
+			secrets = append(secrets, p.Bytes())
+		}
+	}
+	if err := useEntropySnapshot(cfg, "build", secrets); err != nil {
 		return err
 	}
 
-	data := types.Data{
-		IV:   make([]byte, block.BlockSize()),
-		Data: make([]byte, len(rawData)),
+	if err := os.RemoveAll("./parts"); err != nil && !os.IsNotExist(err) {
+		return err
 	}
-
-	if _, err := rand.Read(data.IV); err != nil {
+	if err := os.Mkdir("./parts", 0o755); err != nil {
 		return err
 	}
-	stream := cipher.NewCFBEncrypter(block, data.IV)
-	stream.XORKeyStream(data.Data, rawData)
 
-	buf := &bytes.Buffer{}
-	if err := pTplData.Execute(buf, data); err != nil {
+	if cfg.HistoryDir != "" {
+		if err := resetHistory(cfg); err != nil {
+			return err
+		}
+	}
+
+	// each payload is protected by private key derived from its own seed and shares of required successors
+
+	c, err := createContainers(cfg)
+	if err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile("./parts/data.go", buf.Bytes(), 0o444); err != nil {
+	closed := false
+	defer func() {
+		// on success containers are closed below, so errors of closing them are reported
+		if !closed {
+			_ = c.close()
+		}
+	}()
+
+	var lin lineage
+	defer lin.zero()
+	successorParts := make([]types.Part, len(cfg.Successors))
+	for i := range successorParts {
+		successorParts[i] = types.Part{Trees: map[int]types.SeedNode{}, Shares: map[int][]byte{}}
+	}
+	defer func() {
+		for i := range successorParts {
+			util.ZeroPart(&successorParts[i])
+		}
+	}()
+	payloads := make([]types.Data, 0, len(cfg.Payloads))
+	for i, p := range cfg.Payloads {
+		data, err := generatePayload(ctx, cfg, i, p, required[i], c, &lin, successorParts)
+		if err != nil {
+			return err
+		}
+		payloads = append(payloads, data)
+	}
+	closed = true
+	if err := c.close(); err != nil {
 		return err
 	}
 
 	// create parts
 
-	masterTree := types.SeedNode{Data: seed}
-	buildSeedTree(cfg, &masterTree, map[int]bool{})
-	ss := make([]int, 0, len(cfg.Successors))
+	successors := make([]types.Successor, 0, len(cfg.Successors))
 	for i, s := range cfg.Successors {
-		var sTree types.SeedNode
-		successorTree(&masterTree, &sTree, i)
-		rawTree, err := json.Marshal(sTree)
+		sInfo, err := encryptPart(s, passphrases[i], successorParts[i])
 		if err != nil {
-			panic(err)
+			return err
 		}
+		successors = append(successors, sInfo)
+	}
+	if err := writeParts(cfg, ownerKey, payloads, successors); err != nil {
+		return err
+	}
+
+	lin.Successors = successors
+	return writeLineage(cfg, ownerKey, lin)
+}
 
-		// encrypt part file using symmetric key
+// generatePayload encrypts payload with the key derived from its random secret and distributes the secret
+// between parts of successors, the secret is released as soon as the payload is done
+func generatePayload(ctx context.Context, cfg config.Config, i int, p config.Payload, required []int, c *containers,
+	lin *lineage, successorParts []types.Part) (types.Data, error) {
+	secret := util.NewSecureBuffer(config.SeedSize + len(required)*config.ShareSize)
+	defer secret.Release()
+	if err := util.Random(secret.Bytes()); err != nil {
+		return types.Data{}, err
+	}
 
-		partKey := make([]byte, config.AESKeySize)
-		if _, err := rand.Read(partKey); err != nil {
+	key, header, err := derivePayloadKey(ctx, p, required, secret.Bytes())
+	if err != nil {
+		return types.Data{}, err
+	}
+	defer key.Release()
+
+	lin.Payloads = append(lin.Payloads, lineagePayload{Header: header, Key: append([]byte{}, key.Bytes()...)})
+	data, err := encryptPayload(cfg, p, c, &lin.Payloads[i])
+	if err != nil {
+		return types.Data{}, err
+	}
+
+	masterTree := types.SeedNode{Data: secret.Bytes()[:config.SeedSize]}
+	defer util.ZeroSeedTree(&masterTree)
+	buildSeedTree(len(cfg.Successors), p.RequiredToDecrypt, &masterTree, map[int]bool{})
+	for j := range cfg.Successors {
+		var sTree types.SeedNode
+		successorTree(&masterTree, &sTree, j)
+		successorParts[j].Trees[i] = sTree
+	}
+	for j, r := range required {
+		shareOffset := config.SeedSize + j*config.ShareSize
+		successorParts[r].Shares[i] = append([]byte{}, secret.Bytes()[shareOffset:shareOffset+config.ShareSize]...)
+	}
+	return data, nil
+}
+
+// writeParts generates parts package embedding blobs of payloads and successors signed by the owner
+func writeParts(cfg config.Config, ownerKey ed25519.PrivateKey, payloads []types.Data, successors []types.Successor) error {
+	// sign payloads and parts using owner key
+
+	owner := types.Owner{
+		PublicKey: cfg.OwnerPublicKey,
+		Signature: ed25519.Sign(ownerKey, util.Manifest(payloads, successors)),
+	}
+	blobs := []struct {
+		file  string
+		value interface{}
+	}{
+		{file: "./parts/owner.bin", value: owner},
+		{file: "./parts/payloads.bin", value: payloads},
+		{file: "./parts/successors.bin", value: successors},
+	}
+	for _, b := range blobs {
+		blob, err := util.EncodeBlob(b.value)
+		if err != nil {
 			return err
 		}
-		block, err := aes.NewCipher(partKey)
-		if err != nil {
+		if err := ioutil.WriteFile(b.file, blob, 0o444); err != nil {
 			return err
 		}
+	}
+	if err := ioutil.WriteFile("./parts/parts.go", []byte(partsSource), 0o444); err != nil {
+		return err
+	}
+	fmt.Printf("Legacy signed by the owner, fingerprint of owner key: %s\n", util.Fingerprint(owner.PublicKey))
+	fmt.Println("Give the fingerprint to successors together with their parts, recovery executable trusts only the matching owner key")
+	return nil
+}
 
-		sInfo := types.Successor{
-			PublicKey: s,
-			IV:        make([]byte, block.BlockSize()),
-			Part:      make([]byte, len(rawTree)),
+// requiredSuccessors validates payloads and returns indexes of successors required by each of them
+func requiredSuccessors(cfg config.Config) ([][]int, error) {
+	if len(cfg.Payloads) == 0 {
+		return nil, errors.New("no payloads defined")
+	}
+	if cfg.HistoryDir != "" && cfg.LineageFile == "" {
+		return nil, errors.New("history of payloads requires lineage file")
+	}
+	successors := map[string]int{}
+	for i, s := range cfg.Successors {
+		if s.Name != "" {
+			successors[s.Name] = i
+		}
+	}
+	names := map[string]bool{}
+	required := make([][]int, 0, len(cfg.Payloads))
+	for _, p := range cfg.Payloads {
+		if p.Name == "" || p.Name == "." || p.Name == ".." || strings.ContainsAny(p.Name, `/\`) {
+			return nil, fmt.Errorf("payload name %q is invalid", p.Name)
 		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("payload %s is defined more than once", p.Name)
+		}
+		names[p.Name] = true
+		if p.RequiredToDecrypt < 1 || p.RequiredToDecrypt > len(cfg.Successors) {
+			return nil, fmt.Errorf("payload %s requires %d successors but %d are defined", p.Name, p.RequiredToDecrypt, len(cfg.Successors))
+		}
+		if p.Padding.Bucket < 0 || p.Padding.Size < 0 || (p.Padding.Bucket > 0 && p.Padding.Size > 0) {
+			return nil, fmt.Errorf("padding of payload %s is invalid, either bucket or size might be set", p.Name)
+		}
+		if p.Document && (len(p.Include) > 0 || len(p.Exclude) > 0) {
+			return nil, fmt.Errorf("payload %s is a document, include and exclude patterns can't be used", p.Name)
+		}
+		if p.Sharded && len(cfg.Successors) > payload.MaxShards {
+			return nil, fmt.Errorf("payload %s can't be split into more than %d shards", p.Name, payload.MaxShards)
+		}
+		if len(p.RequiredSuccessors) > p.RequiredToDecrypt {
+			return nil, fmt.Errorf("payload %s lists more required successors than successors required to decrypt it", p.Name)
+		}
+		indexes := make([]int, 0, len(p.RequiredSuccessors))
+		seen := map[int]bool{}
+		for _, name := range p.RequiredSuccessors {
+			i, ok := successors[name]
+			if !ok {
+				return nil, fmt.Errorf("successor %s required by payload %s does not exist", name, p.Name)
+			}
+			if seen[i] {
+				return nil, fmt.Errorf("successor %s is required by payload %s more than once", name, p.Name)
+			}
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+		required = append(required, indexes)
+	}
+	return required, nil
+}
 
-		if _, err := rand.Read(sInfo.IV); err != nil {
-			return err
+// derivePayloadKey derives private key of payload from secret, being the seed followed by shares of required successors,
+// header of payload data is returned too
+func derivePayloadKey(ctx context.Context, p config.Payload, required []int, secret []byte) (*util.SecureBuffer, types.Data, error) {
+	salt := make([]byte, config.SaltSize)
+	if err := util.Random(salt); err != nil {
+		return nil, types.Data{}, err
+	}
+
+	key, err := util.BuildPrivateKey(ctx, secret, salt, types.VersionRandomSalt, util.KeyProgress(os.Stdout, "Encryption"), nil)
+	if err != nil {
+		return nil, types.Data{}, fmt.Errorf("building encryption key for payload %s failed: %w", p.Name, err)
+	}
+	return key, types.Data{
+		Name:               p.Name,
+		Version:            types.VersionStream,
+		RequiredToDecrypt:  p.RequiredToDecrypt,
+		RequiredSuccessors: required,
+		Salt:               salt,
+		KeyCheck:           util.KeyCheck(key.Bytes()),
+	}, nil
+}
+
+// encryptPayload encrypts data of payload using data key wrapped by private key of payload stored in lineage,
+// the result is written to containers, attached to the executable or given to successors later.
+// If history is enabled, data key is kept in lineage and all the versions of data are stored.
+func encryptPayload(cfg config.Config, p config.Payload, c *containers, lp *lineagePayload) (types.Data, error) {
+	dataKey := util.NewSecureBuffer(config.AESKeySize)
+	defer dataKey.Release()
+	if cfg.HistoryDir != "" && lp.DataKey != nil {
+		copy(dataKey.Bytes(), lp.DataKey)
+	} else if err := util.Random(dataKey.Bytes()); err != nil {
+		return types.Data{}, err
+	}
+	wrapKey := util.WrapKey(lp.Key)
+	defer wrapKey.Release()
+
+	data := lp.Header
+	var err error
+	data.WrappedKey, err = util.Seal(wrapKey.Bytes(), dataKey.Bytes())
+	if err != nil {
+		return types.Data{}, err
+	}
+	switch {
+	case cfg.HistoryDir != "":
+		if lp.DataKey == nil {
+			lp.DataKey = append([]byte{}, dataKey.Bytes()...)
+		}
+		if err := addVersion(cfg, p, dataKey.Bytes(), lp); err != nil {
+			return types.Data{}, err
 		}
-		stream := cipher.NewCFBEncrypter(block, sInfo.IV)
-		stream.XORKeyStream(sInfo.Part, rawTree)
+		err = storeHistory(cfg, p, c, lp, &data)
+	case p.Sharded:
+		err = c.writeShards(cfg, p, dataKey.Bytes(), &data)
+	default:
+		err = c.writePayload(cfg, p, dataKey.Bytes(), &data)
+	}
+	if err != nil {
+		return types.Data{}, err
+	}
+	return data, nil
+}
 
-		// encrypt symmetric key using public key of successor
+// encryptData encrypts data file or directory in chunks and writes the result to out, metadata sealed in data are returned
+func encryptData(cfg config.Config, p config.Payload, key []byte, data *types.Data, out io.Writer) (types.Metadata, error) {
+	in, kind, err := openSource(p)
+	if err != nil {
+		return types.Metadata{}, err
+	}
+	defer in.Close()
+	data.Kind = kind
+	data.Codec = cfg.Compression
 
-		pubKey, err := x509.ParsePKCS1PublicKey(s)
+	mw := payload.NewManifestWriter(kind)
+	pad := padFunc(p)
+	size, length, err := payload.Encrypt(out, io.TeeReader(in, mw), key, data, pad)
+	if err != nil {
+		return types.Metadata{}, err
+	}
+	manifest, err := mw.Manifest()
+	if err != nil {
+		return types.Metadata{}, err
+	}
+	now, err := buildTime()
+	if err != nil {
+		return types.Metadata{}, err
+	}
+	metadata := types.Metadata{Manifest: manifest, Time: now, Note: p.Note}
+	if pad != nil {
+		metadata.Padded = true
+		metadata.Length = length
+		fmt.Printf("Payload %s padded, %d bytes of data hidden in %d bytes of encrypted stream\n", p.Name, length, data.Size)
+	}
+	data.Metadata, err = payload.SealMetadata(key, metadata)
+	if err != nil {
+		return types.Metadata{}, err
+	}
+	fmt.Printf("Payload %s: %s\n", p.Name, payload.Summary(manifest))
+	if data.Codec != types.CodecNone && size > 0 {
+		fmt.Printf("Payload %s compressed from %d to %d bytes, compression ratio: %.2f\n", p.Name, size, data.Size, float64(size)/float64(data.Size))
+	}
+	return metadata, nil
+}
+
+// openSource opens data of payload, if payload is a document it is imported from its source file
+func openSource(p config.Payload) (io.ReadCloser, types.Kind, error) {
+	if !p.Document {
+		return payload.OpenSource(p.DataFile, p.Include, p.Exclude, func(file string) {
+			fmt.Fprintf(os.Stderr, "Skipping %s, only regular files, directories and symlinks are packed\n", file)
+		})
+	}
+	in, doc, err := payload.OpenDocument(p.DataFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	fmt.Printf("Payload %s imported from %s: %s\n", p.Name, p.DataFile, payload.DocumentSummary(doc))
+	return in, types.KindDocument, nil
+}
+
+// padFunc returns function computing padded length of data of payload, nil is returned if padding is not configured
+func padFunc(p config.Payload) payload.PadFunc {
+	switch {
+	case p.Padding.Size > 0:
+		return func(length int64) (int64, error) {
+			if length > p.Padding.Size {
+				return 0, fmt.Errorf("payload %s has %d bytes after compression, padding size of %d bytes is too small", p.Name, length, p.Padding.Size)
+			}
+			return p.Padding.Size, nil
+		}
+	case p.Padding.Bucket > 0:
+		return func(length int64) (int64, error) {
+			padded := p.Padding.Bucket
+			for padded < length {
+				if padded > math.MaxInt64/2 {
+					return 0, fmt.Errorf("payload %s is too large to be padded", p.Name)
+				}
+				padded *= 2
+			}
+			return padded, nil
+		}
+	default:
+		return nil
+	}
+}
+
+// minPassphraseLength is the minimum length of passphrase protecting part of passphrase successor
+const minPassphraseLength = 12
+
+// readPassphrases asks the owner for passphrases of passphrase successors, they are never written to disk
+func readPassphrases(cfg config.Config) (map[int]*util.SecureBuffer, error) {
+	passphrases := map[int]*util.SecureBuffer{}
+	for i, s := range cfg.Successors {
+		if !s.Passphrase {
+			if len(s.PublicKey) == 0 {
+				return nil, fmt.Errorf("successor %d has neither public key nor passphrase", i)
+			}
+			continue
+		}
+		if s.Name == "" || len(s.PublicKey) != 0 {
+			return nil, fmt.Errorf("passphrase successor %d must have name and no public key", i)
+		}
+		passphrase, err := readPassphrase(s.Name)
 		if err != nil {
-			return err
+			for _, p := range passphrases {
+				p.Release()
+			}
+			return nil, err
 		}
-		sInfo.Key, err = rsa.EncryptPKCS1v15(rand.Reader, pubKey, partKey)
+		passphrases[i] = passphrase
+	}
+	return passphrases, nil
+}
+
+func readPassphrase(name string) (*util.SecureBuffer, error) {
+	for {
+		fmt.Printf("Enter passphrase for successor %s: ", name)
+		passphrase, err := util.ReadSecret()
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if len(passphrase.Bytes()) < minPassphraseLength {
+			passphrase.Release()
+			fmt.Printf("Passphrase is too short, at least %d characters are required\n", minPassphraseLength)
+			continue
 		}
 
-		buf := &bytes.Buffer{}
-		if err := pTplSuccessor.Execute(buf, successorEntry{Index: i, Data: sInfo}); err != nil {
-			return err
+		fmt.Print("Repeat passphrase: ")
+		repeated, err := util.ReadSecret()
+		if err != nil {
+			passphrase.Release()
+			return nil, err
 		}
-		if err := ioutil.WriteFile(fmt.Sprintf("./parts/s%d.go", i), buf.Bytes(), 0o444); err != nil {
-			return err
+		match := bytes.Equal(passphrase.Bytes(), repeated.Bytes())
+		repeated.Release()
+		if match {
+			return passphrase, nil
 		}
-		ss = append(ss, i)
+		passphrase.Release()
+		fmt.Println("Passphrases do not match")
 	}
-	buf = &bytes.Buffer{}
-	if err := pTplSuccessors.Execute(buf, ss); err != nil {
-		return err
+}
+
+func encryptPart(s config.Successor, passphrase *util.SecureBuffer, part types.Part) (types.Successor, error) {
+	rawPart, err := json.Marshal(part)
+	if err != nil {
+		panic(err)
 	}
-	if err := ioutil.WriteFile("./parts/parts.go", buf.Bytes(), 0o444); err != nil {
-		return err
+	defer util.Zero(rawPart)
+
+	// encrypt part file using symmetric key
+
+	partKey := util.NewSecureBuffer(config.AESKeySize)
+	defer partKey.Release()
+
+	if err := util.Random(partKey.Bytes()); err != nil {
+		return types.Successor{}, err
 	}
-	return nil
+	block, err := aes.NewCipher(partKey.Bytes())
+	if err != nil {
+		return types.Successor{}, err
+	}
+
+	sInfo := types.Successor{
+		Name:      s.Name,
+		PublicKey: s.PublicKey,
+		IV:        make([]byte, block.BlockSize()),
+		Part:      make([]byte, len(rawPart)),
+	}
+
+	if err := util.Random(sInfo.IV); err != nil {
+		return types.Successor{}, err
+	}
+	stream := cipher.NewCFBEncrypter(block, sInfo.IV)
+	stream.XORKeyStream(sInfo.Part, rawPart)
+
+	if s.LetterFile != "" {
+		// personal letter is encrypted using key derived from part key so successor reads it without others
+		sInfo.Letter, err = sealLetter(s.LetterFile, partKey.Bytes())
+		if err != nil {
+			return types.Successor{}, err
+		}
+	}
+
+	if s.Passphrase {
+		// encrypt symmetric key using key derived from passphrase of successor
+
+		sInfo.PassphraseSalt = make([]byte, config.SaltSize)
+		if err := util.Random(sInfo.PassphraseSalt); err != nil {
+			return types.Successor{}, err
+		}
+		passphraseKey := util.PassphraseKey(passphrase.Bytes(), sInfo.PassphraseSalt)
+		defer passphraseKey.Release()
+
+		sInfo.Key, err = util.Seal(passphraseKey.Bytes(), partKey.Bytes())
+		if err != nil {
+			return types.Successor{}, err
+		}
+		return sInfo, nil
+	}
+
+	// encrypt symmetric key using public key of successor
+
+	pubKey, err := x509.ParsePKCS1PublicKey(s.PublicKey)
+	if err != nil {
+		return types.Successor{}, err
+	}
+	sInfo.Key, err = util.EncryptPKCS1v15(pubKey, partKey.Bytes())
+	if err != nil {
+		return types.Successor{}, err
+	}
+	return sInfo, nil
+}
+
+func sealLetter(letterFile string, partKey []byte) ([]byte, error) {
+	letter, err := ioutil.ReadFile(letterFile)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Zero(letter)
+
+	letterKey := util.LetterKey(partKey)
+	defer letterKey.Release()
+
+	return util.Seal(letterKey.Bytes(), letter)
 }
 
 func buildLegacy(ctx context.Context, cfg config.Config, deps build.DepsFunc) error {
 	deps(generateLegacy)
-	return goBuildPkg(ctx, ".", "bin/"+cfg.ExeName)
+	return buildExecutable(ctx, cfg)
 }
 
-const tplSuccessors = `package parts
+// buildExecutable builds executable from generated parts, stores encrypted payloads next to it or attaches them
+// and writes build manifest signed by the owner
+func buildExecutable(ctx context.Context, cfg config.Config) error {
+	ownerKey, err := loadOwnerKey(cfg)
+	if err != nil {
+		return err
+	}
+	_, payloadHash, err := fileHash(payloadFile)
+	if err != nil {
+		return err
+	}
 
-import "github.com/wojciech-malota-wojcik/legacy/types"
+	exeFile := "bin/" + cfg.ExeName
+	if err := goBuildPkg(ctx, ".", exeFile); err != nil {
+		return err
+	}
+	if err := moveShards(cfg); err != nil {
+		return err
+	}
+	if cfg.ExternalPayload {
+		err = os.Rename(payloadFile, externalPayloadFile(cfg))
+	} else {
+		err = payload.Attach(exeFile, payloadFile)
+	}
+	if err != nil {
+		return err
+	}
+	return writeBuildManifest(ctx, cfg, ownerKey, payloadHash)
+}
 
-var Successors = []types.Successor{
-	{{- range $val := . }}
-    successor{{ $val }},{{ end }}
+// externalPayloadFile returns path of the file storing encrypted data next to the executable
+func externalPayloadFile(cfg config.Config) string {
+	return "bin/" + cfg.ExeName + ".payload"
 }
-`
 
-const tplSuccessor = `package parts
+// payloadFile is the container where encrypted payloads are stored before it is attached to the executable
+const payloadFile = "./parts/payload.bin"
 
-import "github.com/wojciech-malota-wojcik/legacy/types"
+// partsSource is the source of parts package decoding blobs embedded in the executable
+const partsSource = `package parts
 
-var successor{{ .Index }} = {{ .Data }}
-`
+import (
+	_ "embed"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
 
-const tplData = `package parts
+//go:embed owner.bin
+var ownerBlob []byte
 
-import "github.com/wojciech-malota-wojcik/legacy/types"
+//go:embed payloads.bin
+var payloadsBlob []byte
 
-var Data = {{ . }}
-`
+//go:embed successors.bin
+var successorsBlob []byte
 
-var pTplSuccessors = template.Must(template.New("").Parse(tplSuccessors))
-var pTplSuccessor = template.Must(template.New("").Parse(tplSuccessor))
-var pTplData = template.Must(template.New("").Parse(tplData))
+// Owner, Payloads and Successors are set by Load
+var (
+	Owner      types.Owner
+	Payloads   []types.Data
+	Successors []types.Successor
+)
 
-type successorEntry struct {
-	Index int
-	Data  types.Successor
+// Load decodes and validates owner, payloads and successors embedded in the executable
+func Load() error {
+	var err error
+	Owner, Payloads, Successors, err = util.LoadParts(ownerBlob, payloadsBlob, successorsBlob)
+	return err
 }
+`
 
-func knownParts(cfg config.Config) {
+func knownParts(numOfSuccessors, requiredToDecrypt int) {
 	leafLen := 1
-	for i := len(cfg.Successors); i >= cfg.RequiredToDecrypt; i-- {
+	for i := numOfSuccessors; i >= requiredToDecrypt; i-- {
 		leafLen *= i
 	}
 	bytesInLeaf := int(math.Floor(float64(config.SeedSize) / float64(leafLen)))
@@ -230,10 +673,9 @@ func knownParts(cfg config.Config) {
 	if bytesInLeaf < 5 {
 		panic("minimum required bytes per leaf is 5, use longer seed")
 	}
-	sLen := len(cfg.Successors)
-	for i := 1; i <= cfg.RequiredToDecrypt; i++ {
+	for i := 1; i <= requiredToDecrypt; i++ {
 		known := 0.
-		for j := sLen; j >= cfg.RequiredToDecrypt; j-- {
+		for j := numOfSuccessors; j >= requiredToDecrypt; j-- {
 			known += (1. - known) * float64(i) / float64(j)
 		}
 		missingBytes := int(math.Floor((1. - known) * float64(config.SeedSize)))
@@ -241,15 +683,15 @@ func knownParts(cfg config.Config) {
 	}
 }
 
-func buildSeedTree(cfg config.Config, node *types.SeedNode, stack map[int]bool) {
-	numOfBuckets := len(cfg.Successors) - len(stack)
-	if numOfBuckets < cfg.RequiredToDecrypt {
+func buildSeedTree(numOfSuccessors, requiredToDecrypt int, node *types.SeedNode, stack map[int]bool) {
+	numOfBuckets := numOfSuccessors - len(stack)
+	if numOfBuckets < requiredToDecrypt {
 		return
 	}
 	node.Sub = map[int]types.SeedNode{}
 	buckets := equalDiv(node.Data, numOfBuckets)
 	bI := 0
-	for i := 0; i < len(cfg.Successors); i++ {
+	for i := 0; i < numOfSuccessors; i++ {
 		if stack[i] {
 			continue
 		}
@@ -258,7 +700,7 @@ func buildSeedTree(cfg config.Config, node *types.SeedNode, stack map[int]bool)
 		}
 		stack[i] = true
 		subNode := types.SeedNode{Data: buckets[bI]}
-		buildSeedTree(cfg, &subNode, stack)
+		buildSeedTree(numOfSuccessors, requiredToDecrypt, &subNode, stack)
 		node.Sub[i] = subNode
 		bI += 1
 		delete(stack, i)
@@ -267,6 +709,10 @@ func buildSeedTree(cfg config.Config, node *types.SeedNode, stack map[int]bool)
 
 func equalDiv(data []byte, numOfBuckets int) [][]byte {
 	buckets := make([][]byte, numOfBuckets)
+	for i := range buckets {
+		// capacity is preallocated so no copies of seed are left in memory by reallocations
+		buckets[i] = make([]byte, 0, (len(data)+numOfBuckets-1)/numOfBuckets)
+	}
 	for i, v := range data {
 		// this will spread bytes of each seed across buckets
 		bucket := i % numOfBuckets
@@ -282,7 +728,8 @@ func successorTree(masterNode *types.SeedNode, successorNode *types.SeedNode, su
 	successorNode.Sub = map[int]types.SeedNode{}
 	for i, mN := range masterNode.Sub {
 		if i == successorIndex {
-			successorNode.Sub[i] = types.SeedNode{Data: mN.Data}
+			// data is copied because seed tree of master is released once payload is generated
+			successorNode.Sub[i] = types.SeedNode{Data: append([]byte{}, mN.Data...)}
 		} else {
 			var node types.SeedNode
 			successorTree(&mN, &node, successorIndex)
@@ -0,0 +1,233 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/wojciech-malota-wojcik/build"
+	"github.com/wojciech-malota-wojcik/ioc"
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+// buildManifest records what went into the executable and what was produced, so builds might be compared
+type buildManifest struct {
+	ExeName      string
+	Config       string
+	Owner        string
+	Successors   []successorFingerprint
+	Payload      string
+	GoVersion    string
+	Reproducible bool
+	Outputs      []outputHash
+}
+
+// successorFingerprint identifies successor by fingerprint of YubiKey public key and hash of part issued to it
+type successorFingerprint struct {
+	Name string
+	Key  string `json:",omitempty"`
+	Part string
+}
+
+// outputHash describes file produced by the build
+type outputHash struct {
+	File string
+	Size int64
+	Hash string
+}
+
+// signedBuildManifest is the build manifest signed by the owner, signature covers exact bytes of Manifest
+type signedBuildManifest struct {
+	Manifest  json.RawMessage
+	PublicKey []byte
+	Signature []byte
+}
+
+// writeBuildManifest writes manifest of the build signed by the owner next to the executable
+func writeBuildManifest(ctx context.Context, cfg config.Config, ownerKey ed25519.PrivateKey, payloadHash string) error {
+	rawCfg, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	goVersion, err := goVersion(ctx)
+	if err != nil {
+		return err
+	}
+	successors, err := successorFingerprints()
+	if err != nil {
+		return err
+	}
+	outputs, err := outputHashes(cfg)
+	if err != nil {
+		return err
+	}
+	manifest := buildManifest{
+		ExeName:      cfg.ExeName,
+		Config:       util.Fingerprint(rawCfg),
+		Owner:        util.Fingerprint(cfg.OwnerPublicKey),
+		Successors:   successors,
+		Payload:      payloadHash,
+		GoVersion:    goVersion,
+		Reproducible: util.DeterministicEntropy(),
+		Outputs:      outputs,
+	}
+	rawManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(signedBuildManifest{
+		Manifest:  rawManifest,
+		PublicKey: cfg.OwnerPublicKey,
+		Signature: ed25519.Sign(ownerKey, util.BuildManifestDigest(rawManifest)),
+	})
+	if err != nil {
+		return err
+	}
+	file := buildManifestFile(cfg)
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := ioutil.WriteFile(file, append(raw, '\n'), 0o444); err != nil {
+		return err
+	}
+	fmt.Printf("Build manifest signed by the owner stored in %s, it is verified against owner key in config by verify-build command\n", file)
+	return nil
+}
+
+func verifyBuildProd(c *ioc.Container, deps build.DepsFunc) {
+	c.Singleton(func() config.Config {
+		return config.Prod
+	})
+	deps(verifyBuild)
+}
+
+func verifyBuildDev(c *ioc.Container, deps build.DepsFunc) {
+	c.Singleton(func() config.Config {
+		return config.Dev
+	})
+	deps(verifyBuild)
+}
+
+// verifyBuild verifies that build manifest is signed by the owner key pinned in config, public key stored
+// in the manifest is not trusted, and that files produced by the build match hashes recorded in it
+func verifyBuild(cfg config.Config) error {
+	file := buildManifestFile(cfg)
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var signed signedBuildManifest
+	if err := json.Unmarshal(raw, &signed); err != nil {
+		return fmt.Errorf("build manifest %s is invalid: %w", file, err)
+	}
+	if len(cfg.OwnerPublicKey) != ed25519.PublicKeySize {
+		return errors.New("public key of the owner is not set in config")
+	}
+	if !bytes.Equal(signed.PublicKey, cfg.OwnerPublicKey) {
+		return fmt.Errorf("build manifest %s is signed by key %s, not by owner key %s", file,
+			util.Fingerprint(signed.PublicKey), util.Fingerprint(cfg.OwnerPublicKey))
+	}
+	if !ed25519.Verify(cfg.OwnerPublicKey, util.BuildManifestDigest(signed.Manifest), signed.Signature) {
+		return fmt.Errorf("signature of build manifest %s is invalid", file)
+	}
+
+	var manifest buildManifest
+	if err := json.Unmarshal(signed.Manifest, &manifest); err != nil {
+		return fmt.Errorf("build manifest %s is invalid: %w", file, err)
+	}
+	if manifest.ExeName != cfg.ExeName || manifest.Owner != util.Fingerprint(cfg.OwnerPublicKey) {
+		return fmt.Errorf("build manifest %s describes another legacy", file)
+	}
+	for _, o := range manifest.Outputs {
+		size, hash, err := fileHash(o.File)
+		if err != nil {
+			return err
+		}
+		if size != o.Size || hash != o.Hash {
+			return fmt.Errorf("file %s does not match build manifest %s", o.File, file)
+		}
+	}
+	fmt.Printf("Build manifest %s is signed by the owner and all %d files produced by the build match it\n", file, len(manifest.Outputs))
+	return nil
+}
+
+// successorFingerprints returns fingerprints of successors embedded in generated parts
+func successorFingerprints() ([]successorFingerprint, error) {
+	blob, err := ioutil.ReadFile("./parts/successors.bin")
+	if err != nil {
+		return nil, err
+	}
+	var successors []types.Successor
+	if err := util.DecodeBlob(blob, &successors); err != nil {
+		return nil, err
+	}
+	fingerprints := make([]successorFingerprint, 0, len(successors))
+	for _, s := range successors {
+		rawPart, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		f := successorFingerprint{Name: s.Name, Part: util.Fingerprint(rawPart)}
+		if s.PublicKey != nil {
+			f.Key = util.Fingerprint(s.PublicKey)
+		}
+		fingerprints = append(fingerprints, f)
+	}
+	return fingerprints, nil
+}
+
+// outputHashes returns hashes of the executable, external payload file and shard files produced by the build
+func outputHashes(cfg config.Config) ([]outputHash, error) {
+	files := []string{"bin/" + cfg.ExeName}
+	if cfg.ExternalPayload {
+		files = append(files, externalPayloadFile(cfg))
+	}
+	for _, p := range cfg.Payloads {
+		if !p.Sharded {
+			continue
+		}
+		for i := range cfg.Successors {
+			files = append(files, shardFile(cfg, i))
+		}
+		break
+	}
+	hashes := make([]outputHash, 0, len(files))
+	for _, file := range files {
+		size, hash, err := fileHash(file)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, outputHash{File: file, Size: size, Hash: hash})
+	}
+	return hashes, nil
+}
+
+// fileHash returns size and hex-encoded SHA-256 hash of file
+func fileHash(file string) (int64, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// buildManifestFile returns path of the build manifest stored next to the executable
+func buildManifestFile(cfg config.Config) string {
+	return "bin/" + cfg.ExeName + ".manifest.json"
+}
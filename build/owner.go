@@ -0,0 +1,97 @@
+package build
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/wojciech-malota-wojcik/build"
+	"github.com/wojciech-malota-wojcik/ioc"
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+const pemTypeOwnerKey = "PRIVATE KEY"
+
+func ownerKeyProd(c *ioc.Container, deps build.DepsFunc) {
+	c.Singleton(func() config.Config {
+		return config.Prod
+	})
+	deps(ownerKey)
+}
+
+func ownerKeyDev(c *ioc.Container, deps build.DepsFunc) {
+	c.Singleton(func() config.Config {
+		return config.Dev
+	})
+	deps(ownerKey)
+}
+
+// ownerKey generates owner key if it does not exist yet and prints its public key to be stored in config
+func ownerKey(cfg config.Config) error {
+	if _, err := os.Stat(cfg.OwnerKeyFile); os.IsNotExist(err) {
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(privKey)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(cfg.OwnerKeyFile, pem.EncodeToMemory(&pem.Block{Type: pemTypeOwnerKey, Bytes: der}), 0o400); err != nil {
+			return err
+		}
+		fmt.Printf("Owner key generated and stored in %s, keep it safe\n\n", cfg.OwnerKeyFile)
+	} else if err != nil {
+		return err
+	}
+
+	privKey, err := readOwnerKey(cfg.OwnerKeyFile)
+	if err != nil {
+		return err
+	}
+	pubKey := privKey.Public().(ed25519.PublicKey)
+	fmt.Printf("Public key of the owner (fingerprint %s):\n\n%#v\n\n", util.Fingerprint(pubKey), []byte(pubKey))
+	return nil
+}
+
+// loadOwnerKey loads private key of the owner and checks that it matches the public key pinned in config
+func loadOwnerKey(cfg config.Config) (ed25519.PrivateKey, error) {
+	if len(cfg.OwnerPublicKey) == 0 {
+		return nil, errors.New("public key of the owner is not set in config, run owner-key command to get it")
+	}
+	privKey, err := readOwnerKey(cfg.OwnerKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(privKey.Public().(ed25519.PublicKey), cfg.OwnerPublicKey) {
+		return nil, errors.New("owner key does not match the public key stored in config")
+	}
+	return privKey, nil
+}
+
+func readOwnerKey(file string) (ed25519.PrivateKey, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != pemTypeOwnerKey {
+		return nil, fmt.Errorf("file %s does not contain owner key", file)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("wrong format of owner key, Ed25519 expected")
+	}
+	return privKey, nil
+}
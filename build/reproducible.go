@@ -0,0 +1,119 @@
+package build
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/payload"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+// sourceDateEpoch is the environment variable fixing time of reproducible build, as defined by reproducible-builds.org
+const sourceDateEpoch = "SOURCE_DATE_EPOCH"
+
+// entropySnapshotSize is the size of entropy snapshot created if it does not exist
+const entropySnapshotSize = 64
+
+// useEntropySnapshot causes random bytes to be generated from entropy snapshot if it is configured,
+// stream of them depends on the command too, so update-data never repeats random bytes generated by build.
+// Secrets are the inputs not stored in config, like passphrases, they change the stream as any other input.
+func useEntropySnapshot(cfg config.Config, command string, secrets [][]byte) error {
+	if cfg.EntropySnapshot == "" {
+		return nil
+	}
+	if _, ok := os.LookupEnv(sourceDateEpoch); !ok {
+		return fmt.Errorf("%s must be set if entropy snapshot is used, otherwise time of the build stored in payloads makes it irreproducible", sourceDateEpoch)
+	}
+	snapshot, err := ioutil.ReadFile(cfg.EntropySnapshot)
+	switch {
+	case os.IsNotExist(err):
+		snapshot = make([]byte, entropySnapshotSize)
+		if _, err := rand.Read(snapshot); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(cfg.EntropySnapshot, snapshot, 0o400); err != nil {
+			return err
+		}
+		fmt.Printf("Entropy snapshot stored in %s, keep it as safe as owner key\n", cfg.EntropySnapshot)
+	case err != nil:
+		return err
+	}
+	defer util.Zero(snapshot)
+
+	if len(snapshot) < entropySnapshotSize {
+		return fmt.Errorf("entropy snapshot %s is too short, at least %d bytes are required", cfg.EntropySnapshot, entropySnapshotSize)
+	}
+	inputs, err := inputsDigest(cfg, command, secrets)
+	if err != nil {
+		return err
+	}
+	if err := util.UseEntropySnapshot(snapshot, inputs); err != nil {
+		return err
+	}
+	fmt.Printf("Random bytes are generated from entropy snapshot %s, build is reproducible\n", cfg.EntropySnapshot)
+	return nil
+}
+
+// inputsDigest computes digest of command, config, time of the build, secrets and data of all the payloads,
+// so random bytes generated from entropy snapshot are never reused to encrypt different data
+func inputsDigest(cfg config.Config, command string, secrets [][]byte) ([]byte, error) {
+	h := sha256.New()
+	e := json.NewEncoder(h)
+	if err := e.Encode(command); err != nil {
+		return nil, err
+	}
+	if err := e.Encode(cfg); err != nil {
+		return nil, err
+	}
+	now, err := buildTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Encode(now.Unix()); err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets {
+		// only hash of secret is written, so encoder never keeps copy of it
+		sum := sha256.Sum256(secret)
+		_, _ = h.Write(sum[:])
+	}
+	for _, p := range cfg.Payloads {
+		var in io.ReadCloser
+		var err error
+		if p.Document {
+			in, _, err = payload.OpenDocument(p.DataFile)
+		} else {
+			in, _, err = payload.OpenSource(p.DataFile, p.Include, p.Exclude, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(h, in)
+		in.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h.Sum(nil), nil
+}
+
+// buildTime returns time of the build stored in metadata of payloads, it is taken from SOURCE_DATE_EPOCH if it is set
+func buildTime() (time.Time, error) {
+	epoch, ok := os.LookupEnv(sourceDateEpoch)
+	if !ok {
+		return time.Now().UTC().Truncate(time.Second), nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s is invalid: %w", sourceDateEpoch, err)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
@@ -0,0 +1,215 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/wojciech-malota-wojcik/build"
+	"github.com/wojciech-malota-wojcik/ioc"
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+// lineage stores private keys of payloads and successor parts, it allows the owner to update data without reissuing parts
+type lineage struct {
+	Payloads   []lineagePayload
+	Successors []types.Successor
+}
+
+// lineagePayload stores header and private key of payload, if history is enabled data key and versions of data are stored too
+type lineagePayload struct {
+	Header   types.Data
+	Key      []byte
+	DataKey  []byte
+	Versions []lineageVersion
+}
+
+func (l *lineage) zero() {
+	for _, p := range l.Payloads {
+		util.Zero(p.Key)
+		util.Zero(p.DataKey)
+	}
+}
+
+// writeLineage stores lineage encrypted using key derived from owner key, it is skipped if lineage file is not configured
+func writeLineage(cfg config.Config, ownerKey ed25519.PrivateKey, lin lineage) error {
+	if cfg.LineageFile == "" {
+		return nil
+	}
+	raw, err := json.Marshal(lin)
+	if err != nil {
+		return err
+	}
+	defer util.Zero(raw)
+
+	lineageKey := util.LineageKey(ownerKey.Seed())
+	defer lineageKey.Release()
+
+	sealed, err := util.Seal(lineageKey.Bytes(), raw)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(cfg.LineageFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := ioutil.WriteFile(cfg.LineageFile, sealed, 0o400); err != nil {
+		return err
+	}
+	fmt.Printf("Lineage stored in %s, keep it safe, it is required to update data\n", cfg.LineageFile)
+	return nil
+}
+
+func readLineage(cfg config.Config, ownerKey ed25519.PrivateKey) (lineage, error) {
+	if cfg.LineageFile == "" {
+		return lineage{}, errors.New("lineage file is not set in config")
+	}
+	sealed, err := ioutil.ReadFile(cfg.LineageFile)
+	if err != nil {
+		return lineage{}, err
+	}
+
+	lineageKey := util.LineageKey(ownerKey.Seed())
+	defer lineageKey.Release()
+
+	raw, err := util.Open(lineageKey.Bytes(), sealed)
+	if err != nil {
+		return lineage{}, fmt.Errorf("decrypting lineage failed: %w", err)
+	}
+	defer raw.Release()
+
+	var lin lineage
+	if err := json.Unmarshal(raw.Bytes(), &lin); err != nil {
+		return lineage{}, err
+	}
+	return lin, nil
+}
+
+func updateLegacyProd(c *ioc.Container, deps build.DepsFunc) {
+	c.Singleton(func() config.Config {
+		return config.Prod
+	})
+	deps(updateLegacy)
+}
+
+func updateLegacyDev(c *ioc.Container, deps build.DepsFunc) {
+	c.Singleton(func() config.Config {
+		return config.Dev
+	})
+	deps(updateLegacy)
+}
+
+func updateLegacy(ctx context.Context, cfg config.Config, deps build.DepsFunc) error {
+	deps(updateData)
+	return buildExecutable(ctx, cfg)
+}
+
+// updateData encrypts current data using private keys stored in lineage, parts of successors are not changed
+func updateData(cfg config.Config) error {
+	required, err := requiredSuccessors(cfg)
+	if err != nil {
+		return err
+	}
+
+	ownerKey, err := loadOwnerKey(cfg)
+	if err != nil {
+		return err
+	}
+
+	lin, err := readLineage(cfg, ownerKey)
+	if err != nil {
+		return err
+	}
+	defer lin.zero()
+
+	if err := checkLineage(cfg, required, lin); err != nil {
+		return err
+	}
+
+	// lineage is an input of update, versions and keys stored there change encrypted data
+	rawLineage, err := ioutil.ReadFile(cfg.LineageFile)
+	if err != nil {
+		return err
+	}
+	if err := useEntropySnapshot(cfg, "update-data", [][]byte{rawLineage}); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll("./parts"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Mkdir("./parts", 0o755); err != nil {
+		return err
+	}
+
+	c, err := createContainers(cfg)
+	if err != nil {
+		return err
+	}
+	closed := false
+	defer func() {
+		// on success containers are closed below, so errors of closing them are reported
+		if !closed {
+			_ = c.close()
+		}
+	}()
+
+	payloads := make([]types.Data, 0, len(cfg.Payloads))
+	for i, p := range cfg.Payloads {
+		data, err := encryptPayload(cfg, p, c, &lin.Payloads[i])
+		if err != nil {
+			return err
+		}
+		payloads = append(payloads, data)
+	}
+	closed = true
+	if err := c.close(); err != nil {
+		return err
+	}
+	if err := writeParts(cfg, ownerKey, payloads, lin.Successors); err != nil {
+		return err
+	}
+	// lineage is stored again because new versions might have been added to history
+	return writeLineage(cfg, ownerKey, lin)
+}
+
+// checkLineage verifies that successors and policies of payloads have not been changed since lineage was created
+func checkLineage(cfg config.Config, required [][]int, lin lineage) error {
+	if len(cfg.Successors) != len(lin.Successors) {
+		return errors.New("successors have been changed, run build to reissue parts")
+	}
+	for i, s := range cfg.Successors {
+		ls := lin.Successors[i]
+		if s.Name != ls.Name || !bytes.Equal(s.PublicKey, ls.PublicKey) || s.Passphrase != ls.IsPassphrase() {
+			return errors.New("successors have been changed, run build to reissue parts")
+		}
+	}
+	if len(cfg.Payloads) != len(lin.Payloads) {
+		return errors.New("payloads have been added or removed, run build to reissue parts")
+	}
+	for i, p := range cfg.Payloads {
+		header := lin.Payloads[i].Header
+		if p.Name != header.Name || p.RequiredToDecrypt != header.RequiredToDecrypt || !equalInts(required[i], header.RequiredSuccessors) {
+			return fmt.Errorf("policy of payload %s has been changed, run build to reissue parts", p.Name)
+		}
+	}
+	return nil
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
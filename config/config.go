@@ -1,17 +1,101 @@
 package config
 
+import "github.com/wojciech-malota-wojcik/legacy/types"
+
 type Config struct {
 	// ExeName is the name of built executable file
 	ExeName string
 
-	// DataFile is the path to data file to encrypt and store
+	// Payloads lists named data stored in the legacy, each one protected by its own policy
+	Payloads []Payload
+
+	// ExternalPayload causes encrypted data to be stored in separate file next to the executable instead of attaching it
+	ExternalPayload bool
+
+	// Compression is the codec used to compress data before encryption
+	Compression types.Codec
+
+	// OwnerKeyFile is the path to file storing private key used by the owner to sign generated data
+	OwnerKeyFile string
+
+	// LineageFile is the path to file storing keys protecting payloads, encrypted using owner key,
+	// it is written by build command and used by update-data command to replace data without reissuing parts
+	LineageFile string
+
+	// HistoryDir is the directory where encrypted versions of payloads are kept, so earlier versions are stored
+	// in the legacy too, update-data command adds new version if data have changed. History requires LineageFile.
+	HistoryDir string
+
+	// EntropySnapshot is the path to file with random bytes replacing system randomness during build, it is created
+	// if it does not exist. Builds from the same snapshot and inputs, with SOURCE_DATE_EPOCH set, produce identical files.
+	// Keys of the legacy are derived from the snapshot, so it must be kept as safe as the owner key.
+	EntropySnapshot string
+
+	// OwnerPublicKey is the public key of the owner pinned in the executable to verify signature of data
+	OwnerPublicKey []byte
+
+	// Successors store successors allowed to decrypt data
+	Successors []Successor
+}
+
+// Payload defines data and successors allowed to decrypt them
+type Payload struct {
+	// Name is the name of payload, it is used to name decrypted file or directory
+	Name string
+
+	// DataFile is the path to data file or directory to encrypt and store
 	DataFile string
 
+	// Include lists patterns of files packed if DataFile is a directory, all files are packed if it is empty
+	Include []string
+
+	// Exclude lists patterns of files and directories skipped if DataFile is a directory
+	Exclude []string
+
+	// Document causes DataFile to be imported as legacy document, it is JSON or YAML file depending on its extension,
+	// files attached to entries are read from paths relative to it
+	Document bool
+
 	// RequiredToDecrypt specifies how many successors have to load their keys to decrypt data
 	RequiredToDecrypt int
 
-	// Successors store public part of keys stored on YubiKeys owned by successors
-	Successors [][]byte
+	// RequiredSuccessors lists names of successors who must be among those loading their keys to decrypt data
+	RequiredSuccessors []string
+
+	// Sharded causes encrypted data to be split into shards using Reed-Solomon code instead of storing them in the executable,
+	// each successor receives one shard file and any RequiredToDecrypt shards are enough to reconstruct data
+	Sharded bool
+
+	// Padding hides real size of data
+	Padding Padding
+
+	// Note is the optional description of version of data being built
+	Note string
+}
+
+// Padding defines how encrypted data are padded with zeros, so their size doesn't reveal size of the content,
+// at most one field might be set
+type Padding struct {
+	// Bucket pads data to the smallest power of two multiple of Bucket bytes
+	Bucket int64
+
+	// Size pads data to fixed size in bytes, build fails if data are larger
+	Size int64
+}
+
+// Successor defines successor owning YubiKey or knowing passphrase
+type Successor struct {
+	// Name is the name of successor, it is required for passphrase successors
+	Name string
+
+	// PublicKey is the public part of key stored on YubiKey owned by successor
+	PublicKey []byte
+
+	// Passphrase is set if successor uses passphrase, entered by the owner during the build, instead of YubiKey
+	Passphrase bool
+
+	// LetterFile is the optional path to text file containing personal letter shown to successor after loading the key
+	LetterFile string
 }
 
 // SeedSize is the byte size of generated seed
@@ -23,34 +107,60 @@ const SeedToKeySteps = 100
 // AESKeySize specifies size of AES key
 const AESKeySize = 32
 
+// SaltSize is the byte size of random salt used to build private key
+const SaltSize = 32
+
+// ShareSize is the byte size of secret share held by each successor required to decrypt payload
+const ShareSize = 32
+
 // Prod is a production config
 var Prod = Config{
-	ExeName:           "my-legacy",
-	DataFile:          "/home/wojciech/legacy.img",
-	RequiredToDecrypt: 3,
-	Successors: [][]byte{
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1},
+	ExeName:      "my-legacy",
+	OwnerKeyFile: "/home/wojciech/legacy-owner.key",
+	LineageFile:  "/home/wojciech/legacy-lineage.bin",
+	Payloads: []Payload{
+		{
+			Name:              "data",
+			DataFile:          "/home/wojciech/legacy.img",
+			RequiredToDecrypt: 3,
+		},
+	},
+	Successors: []Successor{
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
+		{PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xbd, 0xc8, 0x75, 0x71, 0x2, 0x6b, 0xc4, 0xa7, 0x14, 0x16, 0x61, 0xa0, 0x8d, 0x24, 0x85, 0xdd, 0xf8, 0x34, 0xf6, 0x21, 0x8b, 0xbe, 0x17, 0xce, 0xc2, 0xdf, 0x42, 0x32, 0x51, 0xb8, 0xc5, 0x4, 0xe0, 0x6c, 0x7d, 0x63, 0x4a, 0xb9, 0xad, 0xd2, 0xcf, 0x34, 0x81, 0xfd, 0xfc, 0xee, 0xe4, 0xe0, 0x33, 0xeb, 0x5a, 0x6c, 0x40, 0x12, 0x3d, 0x7c, 0x13, 0x6e, 0x93, 0x6b, 0xe, 0x98, 0x90, 0x7a, 0x91, 0x40, 0xbb, 0x35, 0xd9, 0x1, 0x8f, 0x6b, 0x85, 0x56, 0xc7, 0xf7, 0x50, 0x1d, 0xee, 0x20, 0x4d, 0xdc, 0xa5, 0x97, 0x97, 0xeb, 0x81, 0x21, 0x51, 0xc, 0x71, 0xb1, 0x6c, 0x90, 0x46, 0x21, 0x9f, 0xf4, 0xa4, 0xd5, 0xe7, 0x77, 0x10, 0x9a, 0xab, 0x92, 0x6a, 0x40, 0x11, 0xd4, 0x1d, 0x48, 0xa1, 0x74, 0x73, 0xed, 0xad, 0x19, 0x91, 0x56, 0x18, 0xed, 0xb, 0x6c, 0xca, 0x27, 0xef, 0x32, 0x7d, 0xf, 0x95, 0x58, 0xc9, 0xce, 0xee, 0x71, 0xbb, 0x18, 0xff, 0x6d, 0xb6, 0xf0, 0xb8, 0x6a, 0x50, 0x4, 0xde, 0x5, 0xb0, 0xc, 0xe9, 0x83, 0x60, 0xfe, 0x2, 0x84, 0xf6, 0x44, 0xed, 0xc1, 0xc9, 0xdc, 0x9c, 0xa4, 0x53, 0xa0, 0xd3, 0xaf, 0x4a, 0xe3, 0x24, 0x93, 0xef, 0x73, 0xab, 0x14, 0x76, 0x4a, 0xda, 0x98, 0xcb, 0xea, 0x4a, 0x7f, 0x4e, 0xf1, 0x94, 0x56, 0x77, 0xcd, 0x1b, 0x71, 0x13, 0x4f, 0xb6, 0x80, 0x1b, 0xf, 0x41, 0xcd, 0x82, 0xb9, 0x15, 0x51, 0x98, 0xc7, 0xa5, 0xbd, 0x3a, 0xe7, 0xf4, 0xe4, 0x56, 0xf5, 0x0, 0x30, 0x3b, 0xdd, 0xf6, 0xdc, 0xa4, 0x10, 0x81, 0xf, 0x8f, 0xc3, 0xeb, 0xed, 0xe2, 0xe6, 0xfe, 0xe7, 0xd7, 0x2a, 0xf5, 0x23, 0xc8, 0x14, 0xf0, 0xc7, 0xa4, 0x67, 0x9f, 0xe0, 0x49, 0x66, 0xcc, 0xc6, 0xb2, 0xa1, 0x34, 0x36, 0x52, 0xc4, 0xb9, 0x81, 0x2, 0x3, 0x1, 0x0, 0x1}},
 	},
 }
 
 // Dev is a development config
 var Dev = Config{
-	ExeName:           "dev-legacy",
-	DataFile:          "/home/wojciech/legacy-dev.img",
-	RequiredToDecrypt: 2,
-	Successors: [][]byte{
-		// DEV-1
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xa6, 0x88, 0x85, 0x28, 0xb3, 0xd2, 0xf0, 0x75, 0xb8, 0xc4, 0x5f, 0xea, 0x89, 0x63, 0x31, 0xc0, 0xc, 0xf0, 0x28, 0x9e, 0xa7, 0x2c, 0x3f, 0x4, 0xb6, 0x5, 0xaa, 0x52, 0x4f, 0xd8, 0x83, 0x12, 0x25, 0x14, 0x2a, 0x2f, 0xec, 0xdf, 0x71, 0x81, 0x7f, 0x58, 0x4f, 0x77, 0x24, 0x98, 0xb5, 0x5d, 0x2f, 0x8b, 0xbb, 0xf8, 0xdd, 0xea, 0x41, 0x4b, 0x7e, 0xd5, 0xea, 0x60, 0xaa, 0x6b, 0x6c, 0x2a, 0x7e, 0x50, 0xdb, 0xde, 0x2, 0x9c, 0xfb, 0xfa, 0xc2, 0x68, 0x7c, 0x18, 0xa0, 0xf3, 0xc4, 0x73, 0x5d, 0x49, 0x39, 0x6e, 0x5d, 0xf9, 0xc9, 0x45, 0x81, 0xa4, 0x77, 0x95, 0xd6, 0x7, 0x37, 0x5d, 0xf7, 0x2c, 0x69, 0xd9, 0xfe, 0xf3, 0xbf, 0x70, 0x2d, 0xea, 0x11, 0x1e, 0xef, 0x62, 0x9, 0x75, 0x7b, 0x6, 0xee, 0x75, 0x9b, 0x8f, 0xb0, 0xfc, 0x59, 0xef, 0x79, 0xee, 0xa7, 0x1c, 0x5e, 0x11, 0x24, 0x3a, 0x13, 0x9a, 0x66, 0x3c, 0xc1, 0x14, 0x4a, 0xce, 0xed, 0x63, 0xc9, 0x7, 0xe1, 0x61, 0xe9, 0xf6, 0x50, 0xdd, 0xc7, 0xc4, 0xd6, 0x0, 0x32, 0xc2, 0xbe, 0xfe, 0x8d, 0x4f, 0xce, 0x1f, 0x4, 0x30, 0x1a, 0x70, 0xf5, 0x70, 0xd6, 0x43, 0x21, 0x37, 0x7a, 0xc6, 0xb3, 0x5a, 0xac, 0xb1, 0xac, 0x32, 0xab, 0x72, 0xaf, 0xae, 0x77, 0x46, 0x26, 0xd, 0x26, 0xcb, 0x6e, 0x36, 0x52, 0x4d, 0x75, 0x15, 0xc9, 0xd, 0xc, 0xfc, 0x7e, 0x2b, 0x1e, 0xde, 0x15, 0x0, 0x2a, 0xd, 0x60, 0x9e, 0x78, 0x5e, 0x4d, 0x8, 0x9c, 0x35, 0x94, 0xc3, 0x17, 0xcd, 0x95, 0x95, 0x6f, 0x8a, 0xaa, 0xbd, 0x73, 0x35, 0xad, 0x29, 0x2, 0x51, 0x65, 0x17, 0x50, 0xea, 0x51, 0xe5, 0xd6, 0xb0, 0x81, 0x43, 0x20, 0xe1, 0x38, 0xd7, 0xb4, 0x3f, 0xec, 0xe3, 0xbd, 0xff, 0x8f, 0x3b, 0xe0, 0x57, 0xb2, 0x6d, 0x2, 0x3, 0x1, 0x0, 0x1},
-		// DEV-2
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xd3, 0x17, 0x9, 0xfa, 0x5f, 0xf0, 0xfb, 0xe0, 0xce, 0xa0, 0x9d, 0xdb, 0xb5, 0x27, 0xf, 0x83, 0x4a, 0x7, 0x48, 0x14, 0xb0, 0xf, 0xe3, 0x68, 0x21, 0x16, 0x69, 0x9, 0xd8, 0xd0, 0x74, 0xeb, 0x72, 0x86, 0xb5, 0xa0, 0x5a, 0x53, 0x66, 0xc8, 0x47, 0x97, 0x3d, 0x19, 0xc0, 0x8f, 0xcb, 0xe1, 0xf, 0xc1, 0xf7, 0x17, 0x14, 0xfe, 0x7c, 0xfa, 0x37, 0x8d, 0xd2, 0x8b, 0x30, 0x10, 0xad, 0x3d, 0x8d, 0x53, 0x10, 0x84, 0xbe, 0x2, 0x72, 0xc9, 0xf9, 0xd9, 0x12, 0x5e, 0x2b, 0x7d, 0xde, 0xae, 0x10, 0x7d, 0x9, 0xf0, 0x9a, 0xf9, 0x2a, 0xb0, 0x73, 0xfe, 0x9d, 0x35, 0x91, 0x1e, 0xe2, 0x7e, 0x64, 0x43, 0x15, 0x97, 0xa5, 0x65, 0x9d, 0x6d, 0x15, 0x71, 0x2, 0xbf, 0x92, 0x19, 0x39, 0x6a, 0x10, 0x1d, 0xe1, 0x10, 0x51, 0x28, 0x7e, 0x7c, 0xc6, 0x88, 0x16, 0xe0, 0xda, 0x0, 0xb2, 0xd0, 0x2, 0x19, 0x94, 0xae, 0x33, 0x42, 0xf7, 0x6d, 0x85, 0x9, 0xe2, 0x68, 0x54, 0x27, 0x23, 0x35, 0xf2, 0x2f, 0x7d, 0x91, 0x31, 0x98, 0x7e, 0x18, 0x4c, 0xd0, 0x4, 0x8e, 0xa4, 0xa1, 0x13, 0xcc, 0x29, 0xd0, 0xed, 0xd, 0x2e, 0x6e, 0x87, 0x55, 0x2c, 0x5d, 0x94, 0x7b, 0x1b, 0xdd, 0x84, 0xcb, 0xe9, 0x90, 0x7c, 0xc2, 0xfa, 0x4d, 0x1c, 0xcb, 0x96, 0x78, 0x4a, 0xc, 0xcf, 0x55, 0x69, 0xb9, 0x2b, 0x7c, 0x32, 0xa1, 0x6e, 0x4f, 0x5a, 0x48, 0x2b, 0xce, 0xdb, 0xab, 0xea, 0x5, 0xd, 0xc9, 0x32, 0xa0, 0x29, 0x2b, 0x66, 0x5b, 0xb0, 0x25, 0xe3, 0xde, 0xb4, 0xb2, 0xbe, 0x6c, 0x1d, 0x16, 0x21, 0xf9, 0xed, 0x6, 0x71, 0x8d, 0x62, 0x9a, 0x29, 0x65, 0x43, 0x2d, 0x92, 0xa8, 0x34, 0xa, 0x4c, 0x3b, 0xad, 0x2f, 0x6e, 0x26, 0x1, 0x9d, 0xa2, 0x2d, 0x6e, 0x17, 0xd, 0x4, 0xb7, 0xf3, 0x2, 0x3, 0x1, 0x0, 0x1},
-		// DEV-3
-		{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xef, 0x12, 0x43, 0xae, 0x19, 0xe6, 0x7, 0x8c, 0xa3, 0x73, 0x5c, 0xc7, 0xb8, 0x9e, 0xf3, 0x34, 0xc2, 0x42, 0xce, 0x80, 0xde, 0x7, 0x71, 0xf9, 0x22, 0x47, 0xff, 0x94, 0xf8, 0xf0, 0x1c, 0x7e, 0xb6, 0x7e, 0x5, 0x52, 0xc1, 0xd1, 0xb7, 0xeb, 0x88, 0x3c, 0xa2, 0xc, 0x63, 0xff, 0xfe, 0x37, 0x6a, 0xbe, 0x65, 0x75, 0xe8, 0x76, 0x66, 0xc1, 0xb6, 0x41, 0x4b, 0xe5, 0xc5, 0xcc, 0xec, 0xb7, 0xd3, 0xa4, 0x11, 0xa1, 0x27, 0x91, 0x82, 0x7d, 0xab, 0x83, 0xc9, 0xfa, 0x99, 0x6f, 0x96, 0x76, 0x27, 0xba, 0xea, 0x4a, 0x46, 0x4a, 0xd0, 0x9b, 0x22, 0x71, 0xe3, 0x27, 0x9e, 0xfb, 0x6b, 0xe6, 0xb2, 0x27, 0xeb, 0x73, 0xf4, 0x4d, 0x2a, 0x6d, 0x43, 0x5d, 0x46, 0xac, 0xc9, 0xf4, 0x77, 0x9c, 0x72, 0x2, 0xcb, 0xc7, 0xc7, 0xb7, 0xc5, 0xcc, 0x1c, 0xe4, 0x42, 0xb3, 0x30, 0x50, 0x9f, 0x57, 0xa1, 0xe4, 0x3b, 0x53, 0xe9, 0xb9, 0x8a, 0x51, 0x3, 0x24, 0xe6, 0xce, 0xa6, 0xde, 0x71, 0x50, 0x3f, 0x67, 0x8b, 0xe8, 0x21, 0x5e, 0x9a, 0xd9, 0x3b, 0x3d, 0x84, 0x5d, 0x2b, 0x34, 0x49, 0x38, 0x6d, 0xc9, 0x7f, 0x9a, 0xa9, 0x8e, 0x9c, 0xdc, 0x11, 0x22, 0xb1, 0x6a, 0xb9, 0x0, 0x7b, 0xe2, 0x1c, 0x73, 0x22, 0xfb, 0x71, 0xcf, 0x5b, 0xd0, 0x15, 0x62, 0xdf, 0x4c, 0x6f, 0x22, 0x91, 0xde, 0x7b, 0xa9, 0xa2, 0x83, 0xfe, 0x77, 0x96, 0x4, 0x6e, 0xdb, 0xdd, 0x70, 0x71, 0x66, 0x73, 0x44, 0xe1, 0xe4, 0x10, 0x3e, 0x84, 0x5a, 0x31, 0x8a, 0xc8, 0x7b, 0xfc, 0x7d, 0x34, 0x22, 0x4b, 0xcf, 0x51, 0x50, 0xaf, 0xe, 0xd7, 0x9, 0x1c, 0x7f, 0xcf, 0xd5, 0x0, 0x65, 0x91, 0xfd, 0x82, 0x91, 0xbd, 0xf9, 0x75, 0xb5, 0x78, 0xd4, 0x28, 0x2a, 0x38, 0x4b, 0x6d, 0x2b, 0x78, 0xcc, 0xa5, 0xf5, 0x2, 0x3, 0x1, 0x0, 0x1},
+	ExeName:      "dev-legacy",
+	OwnerKeyFile: "/home/wojciech/legacy-dev-owner.key",
+	LineageFile:  "/home/wojciech/legacy-dev-lineage.bin",
+	Payloads: []Payload{
+		{
+			Name:              "data",
+			DataFile:          "/home/wojciech/legacy-dev.img",
+			RequiredToDecrypt: 2,
+		},
+	},
+	Successors: []Successor{
+		{
+			Name:      "DEV-1",
+			PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xa6, 0x88, 0x85, 0x28, 0xb3, 0xd2, 0xf0, 0x75, 0xb8, 0xc4, 0x5f, 0xea, 0x89, 0x63, 0x31, 0xc0, 0xc, 0xf0, 0x28, 0x9e, 0xa7, 0x2c, 0x3f, 0x4, 0xb6, 0x5, 0xaa, 0x52, 0x4f, 0xd8, 0x83, 0x12, 0x25, 0x14, 0x2a, 0x2f, 0xec, 0xdf, 0x71, 0x81, 0x7f, 0x58, 0x4f, 0x77, 0x24, 0x98, 0xb5, 0x5d, 0x2f, 0x8b, 0xbb, 0xf8, 0xdd, 0xea, 0x41, 0x4b, 0x7e, 0xd5, 0xea, 0x60, 0xaa, 0x6b, 0x6c, 0x2a, 0x7e, 0x50, 0xdb, 0xde, 0x2, 0x9c, 0xfb, 0xfa, 0xc2, 0x68, 0x7c, 0x18, 0xa0, 0xf3, 0xc4, 0x73, 0x5d, 0x49, 0x39, 0x6e, 0x5d, 0xf9, 0xc9, 0x45, 0x81, 0xa4, 0x77, 0x95, 0xd6, 0x7, 0x37, 0x5d, 0xf7, 0x2c, 0x69, 0xd9, 0xfe, 0xf3, 0xbf, 0x70, 0x2d, 0xea, 0x11, 0x1e, 0xef, 0x62, 0x9, 0x75, 0x7b, 0x6, 0xee, 0x75, 0x9b, 0x8f, 0xb0, 0xfc, 0x59, 0xef, 0x79, 0xee, 0xa7, 0x1c, 0x5e, 0x11, 0x24, 0x3a, 0x13, 0x9a, 0x66, 0x3c, 0xc1, 0x14, 0x4a, 0xce, 0xed, 0x63, 0xc9, 0x7, 0xe1, 0x61, 0xe9, 0xf6, 0x50, 0xdd, 0xc7, 0xc4, 0xd6, 0x0, 0x32, 0xc2, 0xbe, 0xfe, 0x8d, 0x4f, 0xce, 0x1f, 0x4, 0x30, 0x1a, 0x70, 0xf5, 0x70, 0xd6, 0x43, 0x21, 0x37, 0x7a, 0xc6, 0xb3, 0x5a, 0xac, 0xb1, 0xac, 0x32, 0xab, 0x72, 0xaf, 0xae, 0x77, 0x46, 0x26, 0xd, 0x26, 0xcb, 0x6e, 0x36, 0x52, 0x4d, 0x75, 0x15, 0xc9, 0xd, 0xc, 0xfc, 0x7e, 0x2b, 0x1e, 0xde, 0x15, 0x0, 0x2a, 0xd, 0x60, 0x9e, 0x78, 0x5e, 0x4d, 0x8, 0x9c, 0x35, 0x94, 0xc3, 0x17, 0xcd, 0x95, 0x95, 0x6f, 0x8a, 0xaa, 0xbd, 0x73, 0x35, 0xad, 0x29, 0x2, 0x51, 0x65, 0x17, 0x50, 0xea, 0x51, 0xe5, 0xd6, 0xb0, 0x81, 0x43, 0x20, 0xe1, 0x38, 0xd7, 0xb4, 0x3f, 0xec, 0xe3, 0xbd, 0xff, 0x8f, 0x3b, 0xe0, 0x57, 0xb2, 0x6d, 0x2, 0x3, 0x1, 0x0, 0x1},
+		},
+		{
+			Name:      "DEV-2",
+			PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xd3, 0x17, 0x9, 0xfa, 0x5f, 0xf0, 0xfb, 0xe0, 0xce, 0xa0, 0x9d, 0xdb, 0xb5, 0x27, 0xf, 0x83, 0x4a, 0x7, 0x48, 0x14, 0xb0, 0xf, 0xe3, 0x68, 0x21, 0x16, 0x69, 0x9, 0xd8, 0xd0, 0x74, 0xeb, 0x72, 0x86, 0xb5, 0xa0, 0x5a, 0x53, 0x66, 0xc8, 0x47, 0x97, 0x3d, 0x19, 0xc0, 0x8f, 0xcb, 0xe1, 0xf, 0xc1, 0xf7, 0x17, 0x14, 0xfe, 0x7c, 0xfa, 0x37, 0x8d, 0xd2, 0x8b, 0x30, 0x10, 0xad, 0x3d, 0x8d, 0x53, 0x10, 0x84, 0xbe, 0x2, 0x72, 0xc9, 0xf9, 0xd9, 0x12, 0x5e, 0x2b, 0x7d, 0xde, 0xae, 0x10, 0x7d, 0x9, 0xf0, 0x9a, 0xf9, 0x2a, 0xb0, 0x73, 0xfe, 0x9d, 0x35, 0x91, 0x1e, 0xe2, 0x7e, 0x64, 0x43, 0x15, 0x97, 0xa5, 0x65, 0x9d, 0x6d, 0x15, 0x71, 0x2, 0xbf, 0x92, 0x19, 0x39, 0x6a, 0x10, 0x1d, 0xe1, 0x10, 0x51, 0x28, 0x7e, 0x7c, 0xc6, 0x88, 0x16, 0xe0, 0xda, 0x0, 0xb2, 0xd0, 0x2, 0x19, 0x94, 0xae, 0x33, 0x42, 0xf7, 0x6d, 0x85, 0x9, 0xe2, 0x68, 0x54, 0x27, 0x23, 0x35, 0xf2, 0x2f, 0x7d, 0x91, 0x31, 0x98, 0x7e, 0x18, 0x4c, 0xd0, 0x4, 0x8e, 0xa4, 0xa1, 0x13, 0xcc, 0x29, 0xd0, 0xed, 0xd, 0x2e, 0x6e, 0x87, 0x55, 0x2c, 0x5d, 0x94, 0x7b, 0x1b, 0xdd, 0x84, 0xcb, 0xe9, 0x90, 0x7c, 0xc2, 0xfa, 0x4d, 0x1c, 0xcb, 0x96, 0x78, 0x4a, 0xc, 0xcf, 0x55, 0x69, 0xb9, 0x2b, 0x7c, 0x32, 0xa1, 0x6e, 0x4f, 0x5a, 0x48, 0x2b, 0xce, 0xdb, 0xab, 0xea, 0x5, 0xd, 0xc9, 0x32, 0xa0, 0x29, 0x2b, 0x66, 0x5b, 0xb0, 0x25, 0xe3, 0xde, 0xb4, 0xb2, 0xbe, 0x6c, 0x1d, 0x16, 0x21, 0xf9, 0xed, 0x6, 0x71, 0x8d, 0x62, 0x9a, 0x29, 0x65, 0x43, 0x2d, 0x92, 0xa8, 0x34, 0xa, 0x4c, 0x3b, 0xad, 0x2f, 0x6e, 0x26, 0x1, 0x9d, 0xa2, 0x2d, 0x6e, 0x17, 0xd, 0x4, 0xb7, 0xf3, 0x2, 0x3, 0x1, 0x0, 0x1},
+		},
+		{
+			Name:      "DEV-3",
+			PublicKey: []byte{0x30, 0x82, 0x1, 0xa, 0x2, 0x82, 0x1, 0x1, 0x0, 0xef, 0x12, 0x43, 0xae, 0x19, 0xe6, 0x7, 0x8c, 0xa3, 0x73, 0x5c, 0xc7, 0xb8, 0x9e, 0xf3, 0x34, 0xc2, 0x42, 0xce, 0x80, 0xde, 0x7, 0x71, 0xf9, 0x22, 0x47, 0xff, 0x94, 0xf8, 0xf0, 0x1c, 0x7e, 0xb6, 0x7e, 0x5, 0x52, 0xc1, 0xd1, 0xb7, 0xeb, 0x88, 0x3c, 0xa2, 0xc, 0x63, 0xff, 0xfe, 0x37, 0x6a, 0xbe, 0x65, 0x75, 0xe8, 0x76, 0x66, 0xc1, 0xb6, 0x41, 0x4b, 0xe5, 0xc5, 0xcc, 0xec, 0xb7, 0xd3, 0xa4, 0x11, 0xa1, 0x27, 0x91, 0x82, 0x7d, 0xab, 0x83, 0xc9, 0xfa, 0x99, 0x6f, 0x96, 0x76, 0x27, 0xba, 0xea, 0x4a, 0x46, 0x4a, 0xd0, 0x9b, 0x22, 0x71, 0xe3, 0x27, 0x9e, 0xfb, 0x6b, 0xe6, 0xb2, 0x27, 0xeb, 0x73, 0xf4, 0x4d, 0x2a, 0x6d, 0x43, 0x5d, 0x46, 0xac, 0xc9, 0xf4, 0x77, 0x9c, 0x72, 0x2, 0xcb, 0xc7, 0xc7, 0xb7, 0xc5, 0xcc, 0x1c, 0xe4, 0x42, 0xb3, 0x30, 0x50, 0x9f, 0x57, 0xa1, 0xe4, 0x3b, 0x53, 0xe9, 0xb9, 0x8a, 0x51, 0x3, 0x24, 0xe6, 0xce, 0xa6, 0xde, 0x71, 0x50, 0x3f, 0x67, 0x8b, 0xe8, 0x21, 0x5e, 0x9a, 0xd9, 0x3b, 0x3d, 0x84, 0x5d, 0x2b, 0x34, 0x49, 0x38, 0x6d, 0xc9, 0x7f, 0x9a, 0xa9, 0x8e, 0x9c, 0xdc, 0x11, 0x22, 0xb1, 0x6a, 0xb9, 0x0, 0x7b, 0xe2, 0x1c, 0x73, 0x22, 0xfb, 0x71, 0xcf, 0x5b, 0xd0, 0x15, 0x62, 0xdf, 0x4c, 0x6f, 0x22, 0x91, 0xde, 0x7b, 0xa9, 0xa2, 0x83, 0xfe, 0x77, 0x96, 0x4, 0x6e, 0xdb, 0xdd, 0x70, 0x71, 0x66, 0x73, 0x44, 0xe1, 0xe4, 0x10, 0x3e, 0x84, 0x5a, 0x31, 0x8a, 0xc8, 0x7b, 0xfc, 0x7d, 0x34, 0x22, 0x4b, 0xcf, 0x51, 0x50, 0xaf, 0xe, 0xd7, 0x9, 0x1c, 0x7f, 0xcf, 0xd5, 0x0, 0x65, 0x91, 0xfd, 0x82, 0x91, 0xbd, 0xf9, 0x75, 0xb5, 0x78, 0xd4, 0x28, 0x2a, 0x38, 0x4b, 0x6d, 0x2b, 0x78, 0xcc, 0xa5, 0xf5, 0x2, 0x3, 0x1, 0x0, 0x1},
+		},
 	},
 }
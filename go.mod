@@ -1,11 +1,14 @@
 module github.com/wojciech-malota-wojcik/legacy
 
-go 1.15
+go 1.16
 
 require (
 	github.com/go-piv/piv-go v1.7.0
+	github.com/klauspost/reedsolomon v1.9.11
 	github.com/ridge/must v0.4.0
-	golang.org/x/crypto v0.0.0-20210317152858-513c2a44f670
 	github.com/wojciech-malota-wojcik/build v0.0.0-20210131144749-3ef5b00b908f
 	github.com/wojciech-malota-wojcik/ioc v1.3.1-0.20210124163806-1a91e377508b
+	golang.org/x/crypto v0.0.0-20210317152858-513c2a44f670
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
@@ -11,45 +12,180 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/go-piv/piv-go/piv"
+	"github.com/ridge/must"
 	"github.com/wojciech-malota-wojcik/legacy/config"
 	"github.com/wojciech-malota-wojcik/legacy/parts"
+	"github.com/wojciech-malota-wojcik/legacy/payload"
 	"github.com/wojciech-malota-wojcik/legacy/types"
 	"github.com/wojciech-malota-wojcik/legacy/util"
 )
 
 func main() {
+	output := outputs{paths: payloadFlag{}, commands: payloadFlag{}, extract: payloadPaths{}, versions: payloadFlag{}, stdout: os.Stdout}
+	flag.StringVar(&output.dir, "out", "", "directory where decrypted payloads are stored, named after payloads, directory of the executable by default")
+	flag.Var(output.paths, "to", "path of file or directory where payload is stored, in form of <payload>=<path>, - means standard output, might be repeated")
+	flag.Var(output.commands, "pipe", "shell command receiving payload on standard input, in form of <payload>=<command>, might be repeated")
+	flag.BoolVar(&output.force, "force", false, "overwrite existing files and directories")
+	flag.BoolVar(&output.verify, "verify", false, "rehearse recovery, payloads are decrypted and authenticated but never stored")
+	flag.BoolVar(&output.list, "list", false, "list entries of archive and document payloads instead of storing them")
+	flag.BoolVar(&output.view, "view", false, "show document payloads in terminal viewer instead of storing them")
+	flag.Var(output.extract, "extract", "path of file or directory extracted from archive payload, other entries are skipped, in form of <payload>=<path>, might be repeated")
+	flag.Var(output.versions, "version", "version of payload restored, in form of <payload>=<number>, 1 is the newest one, might be repeated")
+	browseMode := flag.Bool("browse", false, "serve decrypted payloads to web browser on this computer, they are kept in private temporary directory wiped on exit, documents are served as JSON files containing secret values in plain text")
+	mode := flag.String("mode", "0444", "permissions of decrypted file payloads")
+	var storage payload.Storage
+	flag.StringVar(&storage.PayloadFile, "payload", "", "path to the file storing encrypted data if they are not attached to the executable, by default it is searched next to the executable")
+	owner := flag.String("owner", "", "fingerprint of owner key received from the owner, asked for if not given")
+	flag.StringVar(&storage.ShardDir, "shards", "", "directory where shard files of successors are collected, directory of the executable by default")
+	flag.Parse()
+
+	var err error
+	output.mode, err = parseMode(*mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// paths are resolved before working directory is changed
+	for _, p := range []*string{&output.dir, &storage.PayloadFile, &storage.ShardDir} {
+		if *p != "" {
+			*p = must.String(filepath.Abs(*p))
+		}
+	}
+	for name, p := range output.paths {
+		if p != stdoutPath {
+			output.paths[name] = must.String(filepath.Abs(p))
+		}
+	}
 	util.WorkingDir(0)
-	if err := integrate(); err != nil {
+	if !*browseMode {
+		if err := integrate(*owner, output, storage); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// signals are captured before directory is created, so decrypted data are wiped whenever process is interrupted
+	ctx, cancel := util.SignalContext(context.Background())
+	output.browseDir, err = browseDir()
+	if err != nil {
 		log.Fatal(err)
 	}
+	handOver := guardBrowseDir(ctx, output.browseDir)
+	err = integrate(*owner, output, storage)
+	handOver()
+	if err == nil {
+		err = browse(ctx, output.browseDir)
+	}
+	cancel()
+	if err := wipe(output.browseDir); err != nil {
+		log.Printf("Wiping decrypted data failed, remove %s manually: %s", output.browseDir, err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// payloadState tracks secrets gathered to decrypt payload
+type payloadState struct {
+	index    int
+	data     types.Data
+	tree     types.SeedNode
+	shares   map[int]*util.SecureBuffer
+	unlocked bool
+}
+
+// ready returns true if policy of payload is satisfied
+func (ps *payloadState) ready() bool {
+	return !ps.unlocked && ps.tree.Data != nil && len(ps.shares) == len(ps.data.RequiredSuccessors)
+}
+
+// release zeroes secrets gathered for payload
+func (ps *payloadState) release() {
+	util.ZeroSeedTree(&ps.tree)
+	ps.tree = types.SeedNode{}
+	for i, share := range ps.shares {
+		share.Release()
+		delete(ps.shares, i)
+	}
 }
 
-func integrate() error {
+func integrate(owner string, output outputs, storage payload.Storage) error {
+	if err := parts.Load(); err != nil {
+		return fmt.Errorf("legacy embedded in the executable is invalid: %w", err)
+	}
+	if err := util.VerifyOwner(parts.Owner, parts.Payloads, parts.Successors); err != nil {
+		return err
+	}
+	// public key is stored next to the signature, so it is trusted only if it matches fingerprint received from the owner
+	if err := checkOwner(owner, util.Fingerprint(parts.Owner.PublicKey)); err != nil {
+		return err
+	}
+
+	states := make([]*payloadState, 0, len(parts.Payloads))
+	defer func() {
+		for _, ps := range states {
+			ps.release()
+		}
+	}()
+	fmt.Fprintln(os.Stderr, "Legacy contains payloads:")
+	checked := map[string]bool{}
+	for i, data := range parts.Payloads {
+		for j, version := range payload.Versions(data) {
+			// versions of unchanged data share the same encrypted stream
+			stream := fmt.Sprintf("%s:%x", version.PayloadFile, version.Hash)
+			if checked[stream] {
+				continue
+			}
+			checked[stream] = true
+			if err := payload.Check(version, storage); err != nil {
+				return fmt.Errorf("encrypted data of version %d of payload %s are not available: %w", j+1, data.Name, err)
+			}
+		}
+		states = append(states, &payloadState{index: i, data: data, shares: map[int]*util.SecureBuffer{}})
+		if data.ShardFiles == nil {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", data.Name, policy(data))
+		} else {
+			fmt.Fprintf(os.Stderr, "  %s: %s, %d of %d shard(s) found\n", data.Name, policy(data), payload.AvailableShards(data, storage), len(data.ShardFiles))
+		}
+	}
+	if err := output.check(parts.Payloads); err != nil {
+		return err
+	}
+
 	processedPublicKeys := map[string]bool{}
-	var masterTree types.SeedNode
+	processedPassphrases := map[int]bool{}
 
-	fmt.Print("Connect YubiKey and press ENTER...")
-	readline()
-	for masterTree.Data == nil {
+	fmt.Fprint(os.Stderr, "Connect YubiKey and press ENTER...")
+	if _, err := readline(); err != nil {
+		return err
+	}
+	for {
 		cards, err := piv.Cards()
 		if err != nil {
 			return fmt.Errorf("fetching YubiKey devices failed: %w", err)
 		}
 		for _, ykCard := range cards {
+			if locked(states) == 0 {
+				break
+			}
 			if !strings.Contains(strings.ToLower(ykCard), "yubikey") {
 				continue
 			}
 
-			s, partKey, ok, err := decrypt(processedPublicKeys, ykCard)
+			i, partKey, ok, err := decrypt(processedPublicKeys, ykCard)
 			if err != nil {
 				return err
 			}
@@ -57,60 +193,515 @@ func integrate() error {
 				continue
 			}
 
-			// decrypt part file using symmetric key
+			if err := applyPart(states, i, partKey, "PIN"); err != nil {
+				return err
+			}
+			if err := unlockReady(states, output, storage); err != nil {
+				return err
+			}
+		}
+		for i, s := range parts.Successors {
+			if locked(states) == 0 {
+				break
+			}
+			if !s.IsPassphrase() || processedPassphrases[i] {
+				continue
+			}
 
-			block, err := aes.NewCipher(partKey)
+			partKey, ok, err := decryptPassphrase(s)
 			if err != nil {
 				return err
 			}
+			if !ok {
+				continue
+			}
+			processedPassphrases[i] = true
+
+			if err := applyPart(states, i, partKey, "Passphrase"); err != nil {
+				return err
+			}
+			if err := unlockReady(states, output, storage); err != nil {
+				return err
+			}
+		}
+		if locked(states) == 0 {
+			break
+		}
+		fmt.Fprint(os.Stderr, "Connect another YubiKey and press ENTER or type q and press ENTER to finish...")
+		if line, err := readline(); err != nil || strings.TrimSpace(line) == "q" {
+			break
+		}
+	}
+	for _, ps := range states {
+		if !ps.unlocked {
+			fmt.Fprintf(os.Stderr, "Payload %s remains locked\n", ps.data.Name)
+		}
+	}
+	if output.verify {
+		fmt.Fprintln(os.Stderr, "Verification finished, no payload has been stored")
+	}
+	return nil
+}
+
+// checkOwner compares fingerprint of owner key embedded in the executable with the one received from the owner,
+// it is asked for if it is not pinned by the flag
+func checkOwner(pinned, fingerprint string) error {
+	if pinned == "" {
+		if !interactive() {
+			return errors.New("fingerprint of owner key is required, pass it using -owner flag")
+		}
+		fmt.Fprint(os.Stderr, "Type fingerprint of owner key you received from the owner: ")
+		var err error
+		pinned, err = readline()
+		if err != nil {
+			return err
+		}
+	}
+	pinned = strings.ToLower(strings.NewReplacer(" ", "", ":", "", "\t", "").Replace(pinned))
+	if pinned != fingerprint {
+		return fmt.Errorf("fingerprint of owner key %s does not match the one received from the owner, executable is not trusted", fingerprint)
+	}
+	fmt.Fprintf(os.Stderr, "Legacy signed by the owner, fingerprint of owner key: %s\n", fingerprint)
+	return nil
+}
+
+// interactive returns true if standard input is a terminal
+func interactive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// policy returns description of successors required to decrypt payload
+func policy(data types.Data) string {
+	res := fmt.Sprintf("any %d successor(s)", data.RequiredToDecrypt)
+	if len(data.RequiredSuccessors) > 0 {
+		names := make([]string, 0, len(data.RequiredSuccessors))
+		for _, i := range data.RequiredSuccessors {
+			names = append(names, successorName(i))
+		}
+		res = fmt.Sprintf("%d successor(s) including %s", data.RequiredToDecrypt, strings.Join(names, ", "))
+	}
+	return res
+}
+
+func successorName(i int) string {
+	if i < len(parts.Successors) && parts.Successors[i].Name != "" {
+		return parts.Successors[i].Name
+	}
+	return fmt.Sprintf("successor %d", i+1)
+}
+
+// locked returns number of payloads which are still locked
+func locked(states []*payloadState) int {
+	var res int
+	for _, ps := range states {
+		if !ps.unlocked {
+			res++
+		}
+	}
+	return res
+}
+
+// unlockReady decrypts all the payloads whose policy has been satisfied
+func unlockReady(states []*payloadState, output outputs, storage payload.Storage) error {
+	for _, ps := range states {
+		if !ps.ready() {
+			continue
+		}
+		if err := unlock(ps, output, storage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unlock(ps *payloadState, output outputs, storage payload.Storage) error {
+	if !waitForShards(ps.data, storage) {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Seed of payload %s fully integrated, building decryption key, it will take some time...\n", ps.data.Name)
+
+	// secret is the seed followed by shares of required successors
+	secret := util.NewSecureBuffer(len(ps.tree.Data) + len(ps.data.RequiredSuccessors)*config.ShareSize)
+	defer secret.Release()
+	n := copy(secret.Bytes(), ps.tree.Data)
+	for _, i := range ps.data.RequiredSuccessors {
+		n += copy(secret.Bytes()[n:], ps.shares[i].Bytes())
+	}
+	ps.release()
+	ps.unlocked = true
 
-			rawTree := make([]byte, len(s.Part))
+	ctx, cancel := util.SignalContext(context.Background())
+	defer cancel()
 
-			stream := cipher.NewCFBDecrypter(block, s.IV)
-			stream.XORKeyStream(rawTree, s.Part)
+	checkpointFile := checkpointPath(ps.data)
+	fmt.Fprintf(os.Stderr, "Progress is saved to %s, if key generation is interrupted run the executable again to resume it\n", checkpointFile)
 
-			var sTree types.SeedNode
-			if err := json.Unmarshal(rawTree, &sTree); err != nil {
+	key, err := util.BuildPrivateKey(ctx, secret.Bytes(), ps.data.Salt, ps.data.Version, util.KeyProgress(os.Stderr, "Decryption"), util.NewFileCheckpoint(checkpointFile))
+	if err != nil {
+		return fmt.Errorf("building decryption key of payload %s failed: %w", ps.data.Name, err)
+	}
+	defer key.Release()
+
+	secret.Release()
+	if !util.VerifyKey(key.Bytes(), ps.data.KeyCheck) {
+		return fmt.Errorf("decryption key of payload %s is invalid, seed has not been reconstructed correctly", ps.data.Name)
+	}
+
+	dataKey, err := payload.DataKey(ps.data, key.Bytes())
+	if err != nil {
+		return err
+	}
+	defer dataKey.Release()
+
+	fmt.Fprintf(os.Stderr, "Decryption key ready, decrypting payload %s...\n", ps.data.Name)
+	if output.verify {
+		// rehearsal covers all the versions because any of them might be restored later
+		versions := payload.Versions(ps.data)
+		for i, data := range versions {
+			if len(versions) > 1 {
+				fmt.Fprintf(os.Stderr, "Verifying version %d of payload %s\n", i+1, ps.data.Name)
+			}
+			if err := decryptData(data, dataKey.Bytes(), output, storage); err != nil {
 				return err
 			}
-			integratePart(&masterTree, &sTree)
-			fill(&masterTree, map[int]bool{})
-			pr := progress(&masterTree)
-			fmt.Printf("PIN correct, %d%% of seed integrated, missing bytes: %d\n", int(math.Round(100.*float64(pr)/float64(config.SeedSize))), config.SeedSize-pr)
-			if masterTree.Data != nil {
-				break
+		}
+	} else {
+		data, err := chooseVersion(ps.data, dataKey.Bytes(), output)
+		if err != nil {
+			return err
+		}
+		if err := decryptData(data, dataKey.Bytes(), output, storage); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Payload %s decrypted\n", ps.data.Name)
+	return nil
+}
+
+// chooseVersion returns version of payload to restore. It is taken from the flag if it is set, otherwise versions are listed
+// and successor is asked to choose one if standard input is a terminal, the newest one is the default.
+func chooseVersion(data types.Data, key []byte, output outputs) (types.Data, error) {
+	versions := payload.Versions(data)
+	n, err := output.version(data)
+	if err != nil {
+		return types.Data{}, err
+	}
+	if n > 0 {
+		return versions[n-1], nil
+	}
+	if len(versions) == 1 {
+		return versions[0], nil
+	}
+	if !interactive() {
+		fmt.Fprintf(os.Stderr, "Payload %s contains %d versions, the newest one is restored, use -version flag to choose another one\n", data.Name, len(versions))
+		return versions[0], nil
+	}
+	fmt.Fprintf(os.Stderr, "Payload %s contains %d versions:\n", data.Name, len(versions))
+	for i, v := range versions {
+		metadata, ok, err := payload.OpenMetadata(v, key)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "  %d) metadata are invalid: %s\n", i+1, err)
+		case !ok:
+			fmt.Fprintf(os.Stderr, "  %d) metadata are not available\n", i+1)
+		default:
+			fmt.Fprintf(os.Stderr, "  %d) %s, %s\n", i+1, metadata.Time.Local().Format("2006-01-02 15:04"), payload.Summary(metadata.Manifest))
+			if metadata.Note != "" {
+				fmt.Fprintf(os.Stderr, "     %s\n", metadata.Note)
 			}
 		}
-		if masterTree.Data == nil {
-			fmt.Print("Connect another YubiKey and press ENTER...")
-			readline()
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "Choose version to restore or press ENTER to restore the newest one: ")
+		line, err := readline()
+		if err != nil {
+			return types.Data{}, err
+		}
+		if line == "" {
+			return versions[0], nil
+		}
+		n, err := strconv.Atoi(line)
+		if err == nil && n >= 1 && n <= len(versions) {
+			return versions[n-1], nil
+		}
+		fmt.Fprintln(os.Stderr, "Invalid version")
+	}
+}
+
+// waitForShards asks successors to collect shard files until enough of them are available to reconstruct payload,
+// false is returned if they decide to skip the payload for now
+func waitForShards(data types.Data, storage payload.Storage) bool {
+	if data.ShardFiles == nil {
+		return true
+	}
+	for {
+		available := payload.AvailableShards(data, storage)
+		if available >= data.RequiredToDecrypt {
+			return true
+		}
+		fmt.Fprintf(os.Stderr, "Payload %s requires %d shard(s) but %d are available, missing shard files:\n", data.Name, data.RequiredToDecrypt, available)
+		for _, file := range payload.MissingShardFiles(data, storage) {
+			fmt.Fprintf(os.Stderr, "  %s\n", file)
+		}
+		fmt.Fprint(os.Stderr, "Copy shard files of other successors and press ENTER or type q and press ENTER to skip the payload...")
+		line, err := readline()
+		if err != nil || line == "q" {
+			return false
+		}
+	}
+}
+
+// decryptData decrypts payload and stores it in its destination, files and directories are created atomically
+// after entire payload is authenticated and verified against its manifest
+func decryptData(data types.Data, key []byte, output outputs, storage payload.Storage) error {
+	metadata, ok, err := payload.OpenMetadata(data, key)
+	if err != nil {
+		return err
+	}
+
+	dr, err := payload.Decrypt(data, key, metadata, storage)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+
+	mw := payload.NewManifestWriter(data.Kind)
+	r := io.TeeReader(dr, mw)
+	verify := func() error {
+		return verifyManifest(data.Name, mw, metadata.Manifest, ok)
+	}
+
+	dst := output.destination(data)
+	switch {
+	case dst.verify:
+		if err := drain(r); err != nil {
+			return err
+		}
+		return verify()
+	case dst.list:
+		if data.Kind == types.KindArchive {
+			fmt.Fprintf(os.Stderr, "Entries of payload %s:\n", data.Name)
+			if err := payload.List(r, output.stdout); err != nil {
+				return err
+			}
+		}
+		if data.Kind != types.KindDocument {
+			if err := drain(r); err != nil {
+				return err
+			}
+			return verify()
+		}
+		doc, err := readDocument(r, verify)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Entries of payload %s:\n", data.Name)
+		defer doc.release()
+		return payload.ListDocument(doc.Document, output.stdout)
+	case dst.view:
+		doc, err := readDocument(r, verify)
+		if err != nil {
+			return err
+		}
+		defer doc.release()
+		return view(data.Name, doc, output)
+	case dst.command != "":
+		if err := pipe(r, dst.command, output.stdout); err != nil {
+			return err
+		}
+		if err := drain(r); err != nil {
+			return err
+		}
+		return verify()
+	case dst.stdout:
+		if _, err := io.Copy(output.stdout, r); err != nil {
+			return err
+		}
+		return verify()
+	case data.Kind == types.KindArchive:
+		tmp, err := tempDir(dst.path)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		if len(dst.extract) > 0 {
+			err = payload.UnpackSelected(r, tmp, dst.extract)
+		} else {
+			err = payload.Unpack(r, tmp)
+		}
+		if err != nil {
+			return err
+		}
+		if err := drain(r); err != nil {
+			return err
+		}
+		if err := verify(); err != nil {
+			return err
+		}
+		return output.commit(tmp, dst.path)
+	default:
+		tmp, err := writeFile(r, dst.path, output.mode)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp)
+
+		if err := verify(); err != nil {
+			return err
+		}
+		return output.commit(tmp, dst.path)
+	}
+}
+
+// verifyManifest compares decrypted payload with manifest recorded by the owner and prints verification report
+func verifyManifest(name string, mw *payload.ManifestWriter, expected types.Manifest, ok bool) error {
+	actual, err := mw.Manifest()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Verification report of payload %s:\n", name)
+	fmt.Fprintf(os.Stderr, "  %s\n", payload.Summary(actual))
+	if !ok {
+		fmt.Fprintln(os.Stderr, "  manifest is not available, legacy was built before manifests were introduced")
+		return nil
+	}
+	problems := payload.CompareManifests(expected, actual)
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "  %s\n", p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("payload %s does not match its manifest", name)
+	}
+	fmt.Fprintln(os.Stderr, "  content matches manifest recorded by the owner")
+	return nil
+}
+
+// readDocument reads document payload, it is decoded only after entire payload is authenticated and verified,
+// returned document must be released
+func readDocument(r io.Reader, verify func() error) (*document, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Zero(raw)
+
+	if err := verify(); err != nil {
+		return nil, err
+	}
+	doc, err := payload.ReadDocument(raw)
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(doc), nil
+}
+
+// drain reads rest of the payload to authenticate it entirely
+func drain(r io.Reader) error {
+	_, err := io.Copy(ioutil.Discard, r)
+	return err
+}
+
+// writeFile writes payload to temporary file created next to the final one, path of temporary file is returned
+func writeFile(r io.Reader, file string, mode os.FileMode) (tmpFile string, retErr error) {
+	f, err := tempFile(file)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := f.Close(); retErr == nil {
+			retErr = err
 		}
+		if retErr != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return "", err
+	}
+	if err := f.Chmod(mode); err != nil {
+		return "", err
 	}
-	fmt.Println("Seed fully integrated, building decryption key, it will take some time...")
-	key := util.BuildPrivateKey(masterTree.Data)
+	if err := f.Sync(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
 
-	fmt.Println("Decryption key ready, decrypting data...")
-	block, err := aes.NewCipher(key)
+// checkpointPath returns path of the file where state of key generation is stored, it is unique for each legacy
+func checkpointPath(data types.Data) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "legacy", util.Fingerprint(data.Salt)+".checkpoint")
+}
+
+// applyPart integrates part of successor into trees of locked payloads and reports progress, part key is released
+func applyPart(states []*payloadState, successorIndex int, partKey *util.SecureBuffer, secretName string) error {
+	err := integrateSuccessor(states, successorIndex, partKey)
+	partKey.Release()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s correct\n", secretName)
+	for _, ps := range states {
+		if ps.unlocked {
+			continue
+		}
+		pr := progress(&ps.tree)
+		fmt.Fprintf(os.Stderr, "Payload %s: %d%% of seed integrated, missing bytes: %d", ps.data.Name, int(math.Round(100.*float64(pr)/float64(config.SeedSize))), config.SeedSize-pr)
+		if missing := len(ps.data.RequiredSuccessors) - len(ps.shares); missing > 0 {
+			fmt.Fprintf(os.Stderr, ", waiting for %d required successor(s)", missing)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+	return nil
+}
+
+func integrateSuccessor(states []*payloadState, successorIndex int, partKey *util.SecureBuffer) error {
+	s := parts.Successors[successorIndex]
+
+	// decrypt part file using symmetric key
+
+	block, err := aes.NewCipher(partKey.Bytes())
 	if err != nil {
 		return err
 	}
 
-	rawData := make([]byte, len(parts.Data.Data))
+	rawPart := util.NewSecureBuffer(len(s.Part))
+	defer rawPart.Release()
+
+	stream := cipher.NewCFBDecrypter(block, s.IV)
+	stream.XORKeyStream(rawPart.Bytes(), s.Part)
 
-	stream := cipher.NewCFBDecrypter(block, parts.Data.IV)
-	stream.XORKeyStream(rawData, parts.Data.Data)
+	var part types.Part
+	defer util.ZeroPart(&part)
 
-	if err := ioutil.WriteFile("./data.img", rawData, 0o444); err != nil {
+	if err := json.Unmarshal(rawPart.Bytes(), &part); err != nil {
 		return err
 	}
-	fmt.Println("Data decrypted")
+	for _, ps := range states {
+		if ps.unlocked {
+			continue
+		}
+		if sTree, ok := part.Trees[ps.index]; ok {
+			integratePart(&ps.tree, &sTree)
+			fill(&ps.tree, map[int]bool{})
+		}
+		if share, ok := part.Shares[ps.index]; ok && ps.shares[successorIndex] == nil {
+			ps.shares[successorIndex] = util.SecureBufferFrom(append([]byte{}, share...))
+		}
+	}
 	return nil
 }
 
-func decrypt(processedPublicKeys map[string]bool, ykCard string) (successor types.Successor, decryptedKey []byte, ok bool, err error) {
+func decrypt(processedPublicKeys map[string]bool, ykCard string) (successorIndex int, decryptedKey *util.SecureBuffer, ok bool, err error) {
 	yk, err := piv.Open(ykCard)
 	if err != nil {
-		return types.Successor{}, nil, false, fmt.Errorf("opening YubiKey device failed: %w", err)
+		return 0, nil, false, fmt.Errorf("opening YubiKey device failed: %w", err)
 	}
 	defer func() {
 		if err2 := yk.Close(); err == nil && err2 != nil {
@@ -120,61 +711,122 @@ func decrypt(processedPublicKeys map[string]bool, ykCard string) (successor type
 
 	cert, err := yk.Certificate(piv.SlotSignature)
 	if err != nil {
-		return types.Successor{}, nil, false, fmt.Errorf("fetching certificate failed: %w", err)
+		return 0, nil, false, fmt.Errorf("fetching certificate failed: %w", err)
 	}
 
 	pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
 	if !ok {
-		return types.Successor{}, nil, false, errors.New("wrong format of public key on YubiKey, RSA expected")
+		return 0, nil, false, errors.New("wrong format of public key on YubiKey, RSA expected")
 	}
 	pubKeyRaw := x509.MarshalPKCS1PublicKey(pubKey)
 	pubKeyStr := fmt.Sprintf("%x", pubKeyRaw)
 	if processedPublicKeys[pubKeyStr] {
-		fmt.Printf("Hello %s, part of decryption key represented by your YubiKey has been already applied\n", cert.Subject.CommonName)
-		return types.Successor{}, nil, false, nil
+		fmt.Fprintf(os.Stderr, "Hello %s, part of decryption key represented by your YubiKey has been already applied\n", cert.Subject.CommonName)
+		return 0, nil, false, nil
 	}
 
-	s, err := findSuccessor(pubKeyRaw)
+	i, err := findSuccessor(pubKeyRaw)
 	if err != nil {
-		return types.Successor{}, nil, false, err
+		return 0, nil, false, err
 	}
 
-	fmt.Printf("Hello %s, provide your YubiKey PIN: ", cert.Subject.CommonName)
+	fmt.Fprintf(os.Stderr, "Hello %s, provide your YubiKey PIN: ", cert.Subject.CommonName)
 
-	pin := readline()
-	pk, err := yk.PrivateKey(piv.SlotSignature, cert.PublicKey, piv.KeyAuth{PIN: pin, PINPolicy: piv.PINPolicyAlways})
+	pin, err := util.ReadSecret()
 	if err != nil {
-		return types.Successor{}, nil, false, fmt.Errorf("fetching private key failed: %w", err)
+		return 0, nil, false, err
+	}
+	defer pin.Release()
+
+	pk, err := yk.PrivateKey(piv.SlotSignature, cert.PublicKey, piv.KeyAuth{PIN: pin.UnsafeString(), PINPolicy: piv.PINPolicyAlways})
+	if err != nil {
+		return 0, nil, false, fmt.Errorf("fetching private key failed: %w", err)
 	}
 
 	privKey, ok := pk.(crypto.Decrypter)
 	if !ok {
-		return types.Successor{}, nil, false, errors.New("private key stored on YubiKey can't be used for decryption")
+		return 0, nil, false, errors.New("private key stored on YubiKey can't be used for decryption")
 	}
-	decrypted, err := privKey.Decrypt(rand.Reader, s.Key, nil)
+	decrypted, err := privKey.Decrypt(rand.Reader, parts.Successors[i].Key, nil)
 	if err != nil {
-		return types.Successor{}, nil, false, fmt.Errorf("decryption failed: %w", err)
+		return 0, nil, false, fmt.Errorf("decryption failed: %w", err)
 	}
 	processedPublicKeys[pubKeyStr] = true
-	return s, decrypted, true, nil
+
+	partKey := util.SecureBufferFrom(decrypted)
+	if err := showLetter(parts.Successors[i], cert.Subject.CommonName, partKey); err != nil {
+		partKey.Release()
+		return 0, nil, false, err
+	}
+	return i, partKey, true, nil
 }
 
-func findSuccessor(pubKey []byte) (types.Successor, error) {
-	for _, s := range parts.Successors {
+func decryptPassphrase(s types.Successor) (decryptedKey *util.SecureBuffer, ok bool, err error) {
+	fmt.Fprintf(os.Stderr, "Hello %s, provide your passphrase or press ENTER to skip: ", s.Name)
+
+	passphrase, err := util.ReadSecret()
+	if err != nil {
+		return nil, false, err
+	}
+	defer passphrase.Release()
+
+	if len(passphrase.Bytes()) == 0 {
+		return nil, false, nil
+	}
+
+	passphraseKey := util.PassphraseKey(passphrase.Bytes(), s.PassphraseSalt)
+	defer passphraseKey.Release()
+
+	partKey, err := util.Open(passphraseKey.Bytes(), s.Key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Passphrase incorrect")
+		return nil, false, nil
+	}
+	if err := showLetter(s, s.Name, partKey); err != nil {
+		partKey.Release()
+		return nil, false, err
+	}
+	return partKey, true, nil
+}
+
+// showLetter prints personal letter left by the owner to the successor, it is decrypted using key derived from part key
+func showLetter(s types.Successor, name string, partKey *util.SecureBuffer) error {
+	if s.Letter == nil {
+		return nil
+	}
+	letterKey := util.LetterKey(partKey.Bytes())
+	defer letterKey.Release()
+
+	letter, err := util.Open(letterKey.Bytes(), s.Letter)
+	if err != nil {
+		return fmt.Errorf("decrypting personal letter failed: %w", err)
+	}
+	defer letter.Release()
+
+	fmt.Fprintf(os.Stderr, "\n%s, the owner left a personal letter for you:\n\n", name)
+	if _, err := os.Stderr.Write(letter.Bytes()); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
+
+func findSuccessor(pubKey []byte) (int, error) {
+	for i, s := range parts.Successors {
 		if bytes.Equal(pubKey, s.PublicKey) {
-			return s, nil
+			return i, nil
 		}
 	}
-	return types.Successor{}, errors.New("successor not recognized based on public key stored on YubiKey")
+	return 0, errors.New("successor not recognized based on public key stored on YubiKey")
 }
 
-func readline() string {
+func readline() (string, error) {
 	bio := bufio.NewReader(os.Stdin)
 	line, _, err := bio.ReadLine()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 	}
-	return string(line)
+	return string(line), err
 }
 
 func integratePart(masterNode *types.SeedNode, successorNode *types.SeedNode) {
@@ -182,7 +834,8 @@ func integratePart(masterNode *types.SeedNode, successorNode *types.SeedNode) {
 		return
 	}
 	if successorNode.Data != nil {
-		masterNode.Data = successorNode.Data
+		// data is copied so successor tree might be zeroed independently
+		masterNode.Data = append([]byte{}, successorNode.Data...)
 		masterNode.Sub = nil
 		return
 	}
@@ -212,7 +865,6 @@ func fill(masterNode *types.SeedNode, stack map[int]bool) {
 		keys = append(keys, k)
 	}
 	sort.Ints(keys)
-	var data []byte
 	for _, k := range keys {
 		stack[k] = true
 		node := masterNode.Sub[k]
@@ -222,7 +874,6 @@ func fill(masterNode *types.SeedNode, stack map[int]bool) {
 			return
 		}
 		masterNode.Sub[k] = node
-		data = append(data, node.Data...)
 	}
 	dLen := 0
 	for _, k := range keys {
@@ -234,6 +885,9 @@ func fill(masterNode *types.SeedNode, stack map[int]bool) {
 		k := i % expectedChildren
 		masterNode.Data = append(masterNode.Data, masterNode.Sub[keys[k]].Data[j])
 	}
+	for _, sN := range masterNode.Sub {
+		util.ZeroSeedTree(&sN)
+	}
 	masterNode.Sub = nil
 }
 
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wojciech-malota-wojcik/legacy/payload"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// stdoutPath is the path meaning that payload is written to standard output
+const stdoutPath = "-"
+
+// payloadFlag is a repeatable flag mapping payload name to value
+type payloadFlag map[string]string
+
+// String returns string representation of flag
+func (pf payloadFlag) String() string {
+	values := make([]string, 0, len(pf))
+	for name, value := range pf {
+		values = append(values, name+"="+value)
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// Set sets value of payload
+func (pf payloadFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return errors.New("value must be in form of <payload>=<value>")
+	}
+	if _, ok := pf[parts[0]]; ok {
+		return fmt.Errorf("payload %s is set more than once", parts[0])
+	}
+	pf[parts[0]] = parts[1]
+	return nil
+}
+
+// payloadPaths is a repeatable flag mapping payload name to list of paths
+type payloadPaths map[string][]string
+
+// String returns string representation of flag
+func (pp payloadPaths) String() string {
+	values := []string{}
+	for name, paths := range pp {
+		for _, p := range paths {
+			values = append(values, name+"="+p)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// Set adds path to payload
+func (pp payloadPaths) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return errors.New("value must be in form of <payload>=<path>")
+	}
+	pp[parts[0]] = append(pp[parts[0]], parts[1])
+	return nil
+}
+
+// parseMode parses octal permissions
+func parseMode(mode string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || os.FileMode(m)&^os.ModePerm != 0 {
+		return 0, fmt.Errorf("invalid permissions %q", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// outputs defines where decrypted payloads are stored
+type outputs struct {
+	// dir is the directory where payloads are stored by default, named after payloads
+	dir string
+
+	// paths maps payload names to paths of files or directories, stdoutPath means standard output
+	paths payloadFlag
+
+	// commands maps payload names to shell commands receiving payloads on standard input
+	commands payloadFlag
+
+	// force allows existing files and directories to be overwritten
+	force bool
+
+	// mode is the permissions set on decrypted file payloads
+	mode os.FileMode
+
+	// stdout is the standard output where payloads and lists are written, messages are always printed to standard error
+	stdout io.Writer
+
+	// verify causes payloads to be decrypted and authenticated without storing them anywhere
+	verify bool
+
+	// list causes entries of archive and document payloads to be listed instead of storing them
+	list bool
+
+	// view causes document payloads to be shown in terminal viewer instead of storing them
+	view bool
+
+	// extract maps payload names to paths of archive entries restored, other entries are skipped
+	extract payloadPaths
+
+	// versions maps payload names to numbers of versions restored, 1 is the newest one
+	versions payloadFlag
+
+	// browseDir is the private temporary directory where payloads are decrypted to be served over HTTP,
+	// if it is set, payloads are not stored anywhere else
+	browseDir string
+}
+
+// destination is the place where decrypted payload is stored
+type destination struct {
+	path    string
+	stdout  bool
+	command string
+	verify  bool
+	list    bool
+	view    bool
+	extract []string
+}
+
+func (o outputs) destination(data types.Data) destination {
+	if o.verify {
+		return destination{verify: true}
+	}
+	if o.list {
+		return destination{list: true}
+	}
+	if o.view && data.Kind == types.KindDocument {
+		return destination{view: true}
+	}
+	if o.browseDir != "" {
+		if data.Kind == types.KindArchive {
+			return destination{path: filepath.Join(o.browseDir, data.Name), extract: o.extract[data.Name]}
+		}
+		if data.Kind == types.KindDocument {
+			return destination{path: filepath.Join(o.browseDir, data.Name+".json")}
+		}
+		return destination{path: filepath.Join(o.browseDir, data.Name+".img")}
+	}
+	if command, ok := o.commands[data.Name]; ok {
+		return destination{command: command}
+	}
+	if path, ok := o.paths[data.Name]; ok {
+		if path == stdoutPath {
+			return destination{stdout: true}
+		}
+		return destination{path: path, extract: o.extract[data.Name]}
+	}
+	dir := o.dir
+	if dir == "" {
+		dir = "."
+	}
+	if data.Kind == types.KindArchive {
+		return destination{path: filepath.Join(dir, data.Name), extract: o.extract[data.Name]}
+	}
+	if data.Kind == types.KindDocument {
+		return destination{path: filepath.Join(dir, data.Name+".json")}
+	}
+	return destination{path: filepath.Join(dir, data.Name+".img")}
+}
+
+// check verifies that outputs are valid before successors gather their keys
+func (o outputs) check(payloads []types.Data) error {
+	names := map[string]bool{}
+	kinds := map[string]types.Kind{}
+	for _, data := range payloads {
+		names[data.Name] = true
+		kinds[data.Name] = data.Kind
+	}
+	if o.verify && o.list {
+		return errors.New("payloads can't be listed in verification mode")
+	}
+	if (o.verify || o.list) && (len(o.paths) > 0 || len(o.commands) > 0 || len(o.extract) > 0 || o.browseDir != "") {
+		return errors.New("payloads are not stored in verification or list mode")
+	}
+	if o.view && (o.verify || o.list || o.browseDir != "") {
+		return errors.New("documents can't be viewed in verification, list or browse mode")
+	}
+	if o.browseDir != "" && (o.dir != "" || len(o.paths) > 0 || len(o.commands) > 0) {
+		return errors.New("payloads are only served in browse mode, they can't be stored or piped")
+	}
+	for name := range o.extract {
+		if !names[name] {
+			return fmt.Errorf("payload %s does not exist", name)
+		}
+		if kinds[name] != types.KindArchive {
+			return fmt.Errorf("payload %s is not an archive, files can't be extracted from it", name)
+		}
+		if _, ok := o.commands[name]; ok || o.paths[name] == stdoutPath {
+			return fmt.Errorf("files extracted from payload %s must be stored in directory", name)
+		}
+	}
+	if o.verify && len(o.versions) > 0 {
+		return errors.New("all the versions of payloads are verified, they can't be chosen in verification mode")
+	}
+	for name := range o.versions {
+		if !names[name] {
+			return fmt.Errorf("payload %s does not exist", name)
+		}
+	}
+	for _, data := range payloads {
+		if _, err := o.version(data); err != nil {
+			return err
+		}
+	}
+	stdouts := 0
+	for _, pf := range []payloadFlag{o.paths, o.commands} {
+		for name, value := range pf {
+			if !names[name] {
+				return fmt.Errorf("payload %s does not exist", name)
+			}
+			if o.view && kinds[name] == types.KindDocument {
+				return fmt.Errorf("document %s is viewed, it can't be stored or piped", name)
+			}
+			if value == stdoutPath {
+				stdouts++
+			}
+		}
+	}
+	if stdouts > 1 {
+		return errors.New("only one payload might be written to standard output")
+	}
+	for name := range o.commands {
+		if _, ok := o.paths[name]; ok {
+			return fmt.Errorf("payload %s is both written to path and piped to command", name)
+		}
+	}
+
+	for _, data := range payloads {
+		dst := o.destination(data)
+		if dst.path == "" {
+			continue
+		}
+		if err := o.checkPath(dst.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// version returns number of version of payload chosen by the flag, 0 is returned if it is not chosen
+func (o outputs) version(data types.Data) (int, error) {
+	value, ok := o.versions[data.Name]
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > len(payload.Versions(data)) {
+		return 0, fmt.Errorf("payload %s does not contain version %s", data.Name, value)
+	}
+	return n, nil
+}
+
+func (o outputs) checkPath(path string) error {
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", filepath.Dir(path))
+	}
+	if o.force {
+		return nil
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// commit atomically moves temporary file or directory to its final path
+func (o outputs) commit(tmp, path string) error {
+	if err := o.checkPath(path); err != nil {
+		return err
+	}
+	tmpInfo, err := os.Lstat(tmp)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil:
+		// rename replaces existing file atomically, directories have to be removed first
+		if tmpInfo.IsDir() || info.IsDir() {
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// pipe runs shell command with payload passed to its standard input
+func pipe(r io.Reader, command string, stdout io.Writer) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = r
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command %q failed: %w", command, err)
+	}
+	return nil
+}
+
+// tempFile creates temporary file next to the final one
+func tempFile(path string) (*os.File, error) {
+	return ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+}
+
+// tempDir creates temporary directory next to the final one
+func tempDir(path string) (string, error) {
+	return ioutil.TempDir(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+}
@@ -0,0 +1,337 @@
+package payload
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pack writes deterministic tar archive of directory tree to w, entries are filtered by include and exclude patterns.
+// Patterns are matched using path.Match against slash-separated path relative to the directory and against base name.
+// If include patterns are specified, only matching files and their parent directories are packed.
+// Entries other than regular files, directories and symlinks are not packed, skip is called for each of them if it is not nil.
+func Pack(w io.Writer, dir string, include, exclude []string, skip func(file string)) error {
+	tw := tar.NewWriter(w)
+	p := packer{tw: tw, root: dir, include: include, exclude: exclude, skip: skip, written: map[string]bool{}}
+	if err := p.walk(""); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+type packer struct {
+	tw      *tar.Writer
+	root    string
+	include []string
+	exclude []string
+	skip    func(file string)
+	written map[string]bool
+}
+
+// walk packs entries of the directory, they are sorted so archive is always the same for the same tree
+func (p *packer) walk(rel string) error {
+	entries, err := readDirSorted(filepath.Join(p.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 && rel != "" && len(p.include) == 0 {
+		// empty directory
+		return p.writeDir(rel)
+	}
+	for _, info := range entries {
+		entryRel := path.Join(rel, info.Name())
+		if matches(p.exclude, entryRel) {
+			continue
+		}
+		if info.IsDir() {
+			if err := p.walk(entryRel); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(p.include) > 0 && !matches(p.include, entryRel) {
+			continue
+		}
+		if err := p.writeEntry(entryRel, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *packer) writeDir(rel string) error {
+	if rel == "." || rel == "" || p.written[rel] {
+		return nil
+	}
+	if err := p.writeDir(path.Dir(rel)); err != nil {
+		return err
+	}
+	info, err := os.Lstat(filepath.Join(p.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	p.written[rel] = true
+	return p.tw.WriteHeader(header(rel+"/", tar.TypeDir, info, ""))
+}
+
+func (p *packer) writeEntry(rel string, info os.FileInfo) error {
+	if err := p.writeDir(path.Dir(rel)); err != nil {
+		return err
+	}
+	file := filepath.Join(p.root, filepath.FromSlash(rel))
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(file)
+		if err != nil {
+			return err
+		}
+		return p.tw.WriteHeader(header(rel, tar.TypeSymlink, info, filepath.ToSlash(link)))
+	case info.Mode().IsRegular():
+		hdr := header(rel, tar.TypeReg, info, "")
+		hdr.Size = info.Size()
+		if err := p.tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(p.tw, f)
+		return err
+	default:
+		if p.skip != nil {
+			p.skip(file)
+		}
+		return nil
+	}
+}
+
+// header creates tar header containing only the attributes required to restore the tree, ownership is not stored
+func header(name string, typeflag byte, info os.FileInfo, link string) *tar.Header {
+	return &tar.Header{
+		Typeflag: typeflag,
+		Name:     name,
+		Linkname: link,
+		Mode:     int64(info.Mode().Perm()),
+		ModTime:  info.ModTime().Truncate(time.Second),
+		Format:   tar.FormatPAX,
+	}
+}
+
+func readDirSorted(dir string) ([]os.FileInfo, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func matches(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unpack restores directory tree stored in tar archive read from r into dir, dir must not exist or be empty
+func Unpack(r io.Reader, dir string) error {
+	return unpack(r, dir, nil)
+}
+
+// UnpackSelected restores only entries of tar archive read from r selected by paths into dir, path selects file or whole directory.
+// Other entries are skipped without being written, error is returned if any path selects nothing.
+func UnpackSelected(r io.Reader, dir string, paths []string) error {
+	selected := map[string]bool{}
+	for _, p := range paths {
+		selected[CleanEntryPath(p)] = false
+	}
+	if err := unpack(r, dir, selected); err != nil {
+		return err
+	}
+	missing := []string{}
+	for p, found := range selected {
+		if !found {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("paths not found in archive: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CleanEntryPath normalizes path of archive entry given by the user
+func CleanEntryPath(p string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(p), "/"))
+}
+
+// List writes list of entries of tar archive read from r to w
+func List(r io.Reader, w io.Writer) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		info := hdr.FileInfo()
+		name := path.Clean(hdr.Name)
+		if hdr.Typeflag == tar.TypeSymlink {
+			name += " -> " + hdr.Linkname
+		}
+		if _, err := fmt.Fprintf(w, "%s %12d %s %s\n", info.Mode(), info.Size(), hdr.ModTime.Format("2006-01-02 15:04"), name); err != nil {
+			return err
+		}
+	}
+}
+
+// unpack restores entries of tar archive, if selected is not nil only entries selected by its keys are restored
+// and keys are marked once they select any entry
+func unpack(r io.Reader, dir string, selected map[string]bool) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	entries, err := readDirSorted(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+
+	u := unpacker{root: dir, symlinks: map[string]bool{}}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if selected != nil && !isSelected(selected, path.Clean(hdr.Name)) {
+			continue
+		}
+		if err := u.unpack(hdr, tr); err != nil {
+			return err
+		}
+	}
+	return u.finish()
+}
+
+func isSelected(selected map[string]bool, name string) bool {
+	for p := name; ; p = path.Dir(p) {
+		if _, ok := selected[p]; ok {
+			selected[p] = true
+			return true
+		}
+		if p == "." || p == "/" {
+			return false
+		}
+	}
+}
+
+type unpacker struct {
+	root     string
+	symlinks map[string]bool
+	dirs     []*tar.Header
+}
+
+func (u *unpacker) unpack(hdr *tar.Header, r io.Reader) error {
+	name, err := u.entryPath(hdr.Name)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(u.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		// permissions and times of directories are set at the end, otherwise creating their content would fail or modify them
+		if err := os.Mkdir(file, 0o700); err != nil && !os.IsExist(err) {
+			return err
+		}
+		u.dirs = append(u.dirs, hdr)
+		return nil
+	case tar.TypeSymlink:
+		u.symlinks[name] = true
+		return os.Symlink(filepath.FromSlash(hdr.Linkname), file)
+	case tar.TypeReg:
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Chmod(file, os.FileMode(hdr.Mode).Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(file, hdr.ModTime, hdr.ModTime)
+	default:
+		return fmt.Errorf("unsupported type of entry %s", hdr.Name)
+	}
+}
+
+// entryPath validates that entry is stored inside the root directory and not behind any symlink
+func (u *unpacker) entryPath(name string) (string, error) {
+	name = path.Clean(name)
+	if path.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("entry %s is outside of the directory", name)
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if u.symlinks[dir] {
+			return "", fmt.Errorf("entry %s is stored behind symlink", name)
+		}
+	}
+	return name, nil
+}
+
+func (u *unpacker) finish() error {
+	// deeper directories first so setting attributes of parent is not affected by its children
+	for i := len(u.dirs) - 1; i >= 0; i-- {
+		hdr := u.dirs[i]
+		name, err := u.entryPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(u.root, filepath.FromSlash(name))
+		if err := os.Chmod(file, os.FileMode(hdr.Mode).Perm()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(file, hdr.ModTime, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
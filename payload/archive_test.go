@@ -0,0 +1,234 @@
+package payload
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testModTime = time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+
+// createTestTree creates directory tree containing files, empty directory and symlink
+func createTestTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := []struct {
+		name    string
+		content []byte
+		mode    os.FileMode
+	}{
+		{name: "a.txt", content: []byte("a"), mode: 0o644},
+		{name: "empty.txt", content: nil, mode: 0o600},
+		{name: "docs/will.pdf", content: testPlaintext(3*ChunkSize + 5), mode: 0o400},
+		{name: "docs/notes/b.txt", content: []byte("b"), mode: 0o640},
+		{name: "photos/1.jpg", content: testPlaintext(100), mode: 0o644},
+		{name: "photos/cache/tmp.bin", content: []byte("tmp"), mode: 0o644},
+	}
+	for _, f := range files {
+		file := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, f.content, f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(file, f.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, testModTime, testModTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../a.txt", filepath.Join(dir, "docs", "link")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// describeTree returns description of each entry of directory tree, indexed by its relative path
+func describeTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	tree := map[string]string{}
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || file == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			tree[filepath.ToSlash(rel)] = "symlink " + link
+		case info.IsDir():
+			tree[filepath.ToSlash(rel)] = fmt.Sprintf("dir %s", info.Mode().Perm())
+		default:
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			tree[filepath.ToSlash(rel)] = fmt.Sprintf("file %s %s %x", info.Mode().Perm(), info.ModTime().UTC(), content)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func pack(t *testing.T, dir string, include, exclude []string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := Pack(buf, dir, include, exclude, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestPackUnpackRoundTrip(t *testing.T) {
+	src := createTestTree(t)
+	expected := describeTree(t, src)
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		paths    []string
+		expected []string
+		// parents are directories not selected by paths, created only to store selected entries
+		parents []string
+	}{
+		{
+			name:     "whole tree",
+			expected: []string{"a.txt", "empty.txt", "empty", "docs", "docs/will.pdf", "docs/notes", "docs/notes/b.txt", "docs/link", "photos", "photos/1.jpg", "photos/cache", "photos/cache/tmp.bin"},
+		},
+		{
+			name:     "excluded directory",
+			exclude:  []string{"photos/cache"},
+			expected: []string{"a.txt", "empty.txt", "empty", "docs", "docs/will.pdf", "docs/notes", "docs/notes/b.txt", "docs/link", "photos", "photos/1.jpg"},
+		},
+		{
+			name:     "included base names",
+			include:  []string{"*.txt"},
+			expected: []string{"a.txt", "empty.txt", "docs", "docs/notes", "docs/notes/b.txt"},
+		},
+		{
+			name:     "included and excluded",
+			include:  []string{"*.txt", "*.jpg"},
+			exclude:  []string{"docs"},
+			expected: []string{"a.txt", "empty.txt", "photos", "photos/1.jpg"},
+		},
+		{
+			name:     "selected paths",
+			paths:    []string{"docs/notes", "/a.txt"},
+			expected: []string{"a.txt", "docs/notes", "docs/notes/b.txt"},
+			parents:  []string{"docs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := pack(t, src, tt.include, tt.exclude)
+			if !bytes.Equal(archive, pack(t, src, tt.include, tt.exclude)) {
+				t.Fatal("archive is not deterministic")
+			}
+
+			dst := filepath.Join(t.TempDir(), "restored")
+			var err error
+			if tt.paths == nil {
+				err = Unpack(bytes.NewReader(archive), dst)
+			} else {
+				err = UnpackSelected(bytes.NewReader(archive), dst, tt.paths)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			restored := describeTree(t, dst)
+			want := map[string]string{}
+			for _, name := range tt.expected {
+				want[name] = expected[name]
+			}
+			for _, name := range tt.parents {
+				want[name] = "dir -rwx------"
+			}
+			if !reflect.DeepEqual(restored, want) {
+				t.Fatalf("restored tree differs\nexpected: %v\ngot:      %v", want, restored)
+			}
+		})
+	}
+}
+
+func TestUnpackSelectedMissingPath(t *testing.T) {
+	archive := pack(t, createTestTree(t), nil, nil)
+	err := UnpackSelected(bytes.NewReader(archive), filepath.Join(t.TempDir(), "restored"), []string{"a.txt", "missing"})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected error reporting missing path, got %v", err)
+	}
+}
+
+func TestUnpackNonEmptyDirectory(t *testing.T) {
+	archive := pack(t, createTestTree(t), nil, nil)
+	dst := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dst, "existing"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unpack(bytes.NewReader(archive), dst); err == nil {
+		t.Fatal("archive unpacked into non-empty directory")
+	}
+}
+
+func TestUnpackMaliciousArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+	}{
+		{name: "parent directory", headers: []*tar.Header{
+			{Typeflag: tar.TypeReg, Name: "../evil", Mode: 0o644},
+		}},
+		{name: "nested parent directory", headers: []*tar.Header{
+			{Typeflag: tar.TypeReg, Name: "a/../../evil", Mode: 0o644},
+		}},
+		{name: "absolute path", headers: []*tar.Header{
+			{Typeflag: tar.TypeReg, Name: "/tmp/evil", Mode: 0o644},
+		}},
+		{name: "behind symlink", headers: []*tar.Header{
+			{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "/tmp", Mode: 0o777},
+			{Typeflag: tar.TypeReg, Name: "link/evil", Mode: 0o644},
+		}},
+		{name: "device", headers: []*tar.Header{
+			{Typeflag: tar.TypeChar, Name: "device", Mode: 0o644},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tw := tar.NewWriter(buf)
+			for _, hdr := range tt.headers {
+				if err := tw.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := Unpack(buf, filepath.Join(t.TempDir(), "restored")); err == nil {
+				t.Fatal("malicious archive has been unpacked")
+			}
+		})
+	}
+}
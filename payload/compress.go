@@ -0,0 +1,43 @@
+package payload
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// NewCompressingWriter returns writer compressing data using codec, Close must be called to flush compressed data
+func NewCompressingWriter(w io.Writer, codec types.Codec) (io.WriteCloser, error) {
+	switch codec {
+	case types.CodecNone:
+		return nopWriteCloser{Writer: w}, nil
+	case types.CodecGzip:
+		// header of gzip stream is left empty so output is deterministic
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %d", codec)
+	}
+}
+
+// NewDecompressingReader returns reader decompressing data using codec
+func NewDecompressingReader(r io.Reader, codec types.Codec) (io.ReadCloser, error) {
+	switch codec {
+	case types.CodecNone:
+		return ioutil.NopCloser(r), nil
+	case types.CodecGzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %d", codec)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,193 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"gopkg.in/yaml.v2"
+)
+
+// documentSource is the legacy document written by the owner, attachments are paths of files relative to the source
+type documentSource struct {
+	Title        string           `json:"title" yaml:"title"`
+	Instructions string           `json:"instructions" yaml:"instructions"`
+	Categories   []categorySource `json:"categories" yaml:"categories"`
+	Entries      []entrySource    `json:"entries" yaml:"entries"`
+}
+
+type categorySource struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+}
+
+type entrySource struct {
+	Title       string        `json:"title" yaml:"title"`
+	Category    string        `json:"category" yaml:"category"`
+	Fields      []fieldSource `json:"fields" yaml:"fields"`
+	Notes       string        `json:"notes" yaml:"notes"`
+	Attachments []string      `json:"attachments" yaml:"attachments"`
+}
+
+type fieldSource struct {
+	Name   string `json:"name" yaml:"name"`
+	Value  string `json:"value" yaml:"value"`
+	Secret bool   `json:"secret" yaml:"secret"`
+}
+
+// importDocument reads legacy document from JSON or YAML source file, files attached to entries are embedded in it
+func importDocument(sourceFile string) (types.Document, error) {
+	raw, err := ioutil.ReadFile(sourceFile)
+	if err != nil {
+		return types.Document{}, err
+	}
+
+	var src documentSource
+	switch strings.ToLower(filepath.Ext(sourceFile)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&src)
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(raw, &src)
+	default:
+		return types.Document{}, fmt.Errorf("format of document %s is unknown, use .json, .yaml or .yml extension", sourceFile)
+	}
+	if err != nil {
+		return types.Document{}, fmt.Errorf("parsing document %s failed: %w", sourceFile, err)
+	}
+
+	doc := types.Document{Title: src.Title, Instructions: src.Instructions}
+	for _, c := range src.Categories {
+		doc.Categories = append(doc.Categories, types.Category{Name: c.Name, Description: c.Description})
+	}
+	for _, e := range src.Entries {
+		entry := types.Entry{Title: e.Title, Category: e.Category, Notes: e.Notes}
+		for _, f := range e.Fields {
+			entry.Fields = append(entry.Fields, types.Field{Name: f.Name, Value: f.Value, Secret: f.Secret})
+		}
+		for _, a := range e.Attachments {
+			file := a
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(sourceFile), file)
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				return types.Document{}, fmt.Errorf("attachment of entry %q is not available: %w", e.Title, err)
+			}
+			if !info.Mode().IsRegular() {
+				return types.Document{}, fmt.Errorf("attachment %s of entry %q is not a regular file", file, e.Title)
+			}
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return types.Document{}, err
+			}
+			entry.Attachments = append(entry.Attachments, types.Attachment{Name: filepath.Base(file), Data: data})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	if err := ValidateDocument(doc); err != nil {
+		return types.Document{}, fmt.Errorf("document %s is invalid: %w", sourceFile, err)
+	}
+	return doc, nil
+}
+
+// OpenDocument imports legacy document from source file and returns its JSON encoding being the plaintext of payload
+func OpenDocument(sourceFile string) (io.ReadCloser, types.Document, error) {
+	doc, err := importDocument(sourceFile)
+	if err != nil {
+		return nil, types.Document{}, err
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, types.Document{}, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(raw)), doc, nil
+}
+
+// ReadDocument decodes legacy document from decrypted payload and validates it
+func ReadDocument(raw []byte) (types.Document, error) {
+	var doc types.Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return types.Document{}, fmt.Errorf("decoding document failed: %w", err)
+	}
+	if err := ValidateDocument(doc); err != nil {
+		return types.Document{}, err
+	}
+	return doc, nil
+}
+
+// ValidateDocument verifies that document has title, entries have titles and belong to defined categories,
+// and names of attachments are unique file names
+func ValidateDocument(doc types.Document) error {
+	if strings.TrimSpace(doc.Title) == "" {
+		return errors.New("title is empty")
+	}
+	categories := map[string]bool{}
+	for _, c := range doc.Categories {
+		if strings.TrimSpace(c.Name) == "" {
+			return errors.New("name of category is empty")
+		}
+		if categories[c.Name] {
+			return fmt.Errorf("category %q is defined more than once", c.Name)
+		}
+		categories[c.Name] = true
+	}
+	for _, e := range doc.Entries {
+		if strings.TrimSpace(e.Title) == "" {
+			return errors.New("title of entry is empty")
+		}
+		if e.Category != "" && !categories[e.Category] {
+			return fmt.Errorf("category %q of entry %q is not defined", e.Category, e.Title)
+		}
+		for _, f := range e.Fields {
+			if strings.TrimSpace(f.Name) == "" {
+				return fmt.Errorf("name of field in entry %q is empty", e.Title)
+			}
+		}
+		names := map[string]bool{}
+		for _, a := range e.Attachments {
+			if a.Name == "" || a.Name == "." || a.Name == ".." || a.Name != filepath.Base(a.Name) || strings.ContainsAny(a.Name, `/\`) {
+				return fmt.Errorf("name of attachment %q in entry %q is invalid", a.Name, e.Title)
+			}
+			if names[a.Name] {
+				return fmt.Errorf("attachment %q is attached to entry %q more than once", a.Name, e.Title)
+			}
+			names[a.Name] = true
+		}
+	}
+	return nil
+}
+
+// DocumentSummary returns short description of document content
+func DocumentSummary(doc types.Document) string {
+	attachments := 0
+	for _, e := range doc.Entries {
+		attachments += len(e.Attachments)
+	}
+	return fmt.Sprintf("document %q, %d entries in %d categories, %d attachment(s)", doc.Title, len(doc.Entries), len(doc.Categories), attachments)
+}
+
+// ListDocument writes titles of document entries to w, secret values are not revealed
+func ListDocument(doc types.Document, w io.Writer) error {
+	for i, e := range doc.Entries {
+		line := fmt.Sprintf("%4d  %s", i+1, e.Title)
+		if e.Category != "" {
+			line += " [" + e.Category + "]"
+		}
+		if len(e.Attachments) > 0 {
+			line += fmt.Sprintf(", %d attachment(s)", len(e.Attachments))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package payload
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// ManifestWriter computes manifest of plaintext written to it, for archives each regular file is described too
+type ManifestWriter struct {
+	hash  hash.Hash
+	size  int64
+	pw    *io.PipeWriter
+	files chan filesResult
+}
+
+type filesResult struct {
+	files []types.File
+	err   error
+}
+
+// NewManifestWriter returns writer computing manifest of payload of the kind
+func NewManifestWriter(kind types.Kind) *ManifestWriter {
+	mw := &ManifestWriter{hash: sha256.New()}
+	if kind == types.KindArchive {
+		pr, pw := io.Pipe()
+		mw.pw = pw
+		mw.files = make(chan filesResult, 1)
+		go func() {
+			files, err := archiveFiles(pr)
+			// rest of the stream is consumed so writer is never blocked
+			_, _ = io.Copy(ioutil.Discard, pr)
+			mw.files <- filesResult{files: files, err: err}
+		}()
+	}
+	return mw
+}
+
+// Write adds data to manifest
+func (mw *ManifestWriter) Write(p []byte) (int, error) {
+	_, _ = mw.hash.Write(p)
+	mw.size += int64(len(p))
+	if mw.pw != nil {
+		if _, err := mw.pw.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Manifest returns manifest of all the data written, it must be called once, after all the data are written
+func (mw *ManifestWriter) Manifest() (types.Manifest, error) {
+	manifest := types.Manifest{Size: mw.size, Hash: mw.hash.Sum(nil)}
+	if mw.pw == nil {
+		return manifest, nil
+	}
+	_ = mw.pw.Close()
+	res := <-mw.files
+	if res.err != nil {
+		return types.Manifest{}, fmt.Errorf("reading archive failed: %w", res.err)
+	}
+	manifest.Files = res.files
+	return manifest, nil
+}
+
+func archiveFiles(r io.Reader) ([]types.File, error) {
+	files := []types.File{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		h := sha256.New()
+		size, err := io.Copy(h, tr)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, types.File{Path: hdr.Name, Size: size, Hash: h.Sum(nil)})
+	}
+}
+
+// Summary returns short description of manifest
+func Summary(manifest types.Manifest) string {
+	res := fmt.Sprintf("%d bytes", manifest.Size)
+	if manifest.Files != nil {
+		res += fmt.Sprintf(", %d file(s)", len(manifest.Files))
+	}
+	return res + fmt.Sprintf(", SHA-256: %x", manifest.Hash)
+}
+
+// CompareManifests returns list of differences between expected and actual manifest
+func CompareManifests(expected, actual types.Manifest) []string {
+	var res []string
+	if expected.Size != actual.Size {
+		res = append(res, fmt.Sprintf("size is %d bytes, %d expected", actual.Size, expected.Size))
+	}
+	if !bytes.Equal(expected.Hash, actual.Hash) {
+		res = append(res, fmt.Sprintf("SHA-256 is %x, %x expected", actual.Hash, expected.Hash))
+	}
+
+	actualFiles := map[string]types.File{}
+	for _, f := range actual.Files {
+		actualFiles[f.Path] = f
+	}
+	for _, ef := range expected.Files {
+		af, ok := actualFiles[ef.Path]
+		delete(actualFiles, ef.Path)
+		switch {
+		case !ok:
+			res = append(res, fmt.Sprintf("file %s is missing", ef.Path))
+		case ef.Size != af.Size:
+			res = append(res, fmt.Sprintf("file %s has %d bytes, %d expected", ef.Path, af.Size, ef.Size))
+		case !bytes.Equal(ef.Hash, af.Hash):
+			res = append(res, fmt.Sprintf("file %s has SHA-256 %x, %x expected", ef.Path, af.Hash, ef.Hash))
+		}
+	}
+	for _, af := range actual.Files {
+		if _, ok := actualFiles[af.Path]; ok {
+			res = append(res, fmt.Sprintf("file %s is unexpected", af.Path))
+		}
+	}
+	return res
+}
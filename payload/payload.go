@@ -0,0 +1,253 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+// OpenSource opens data file, if it is a directory, tree is packed using include and exclude patterns,
+// skip is called for each entry which is not packed
+func OpenSource(dataFile string, include, exclude []string, skip func(file string)) (io.ReadCloser, types.Kind, error) {
+	info, err := os.Stat(dataFile)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !info.IsDir() {
+		f, err := os.Open(dataFile)
+		if err != nil {
+			return nil, 0, err
+		}
+		return f, types.KindFile, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(Pack(pw, dataFile, include, exclude, skip))
+	}()
+	return pr, types.KindArchive, nil
+}
+
+// PadFunc returns length of padded data of length, no padding is added if it returns length
+type PadFunc func(length int64) (int64, error)
+
+// Encrypt compresses data read from r using codec set in data, pads them using pad if it is not nil, encrypts them
+// and writes the result to w. Nonce prefix, size and hash of encrypted payload are stored in data.
+// Number of bytes read from r and length of compressed data preceding padding are returned.
+func Encrypt(w io.Writer, r io.Reader, key []byte, data *types.Data, pad PadFunc) (int64, int64, error) {
+	data.IV = make([]byte, NoncePrefixSize)
+	if err := util.Random(data.IV); err != nil {
+		return 0, 0, err
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(w, hash)}
+	ew, err := NewEncryptingWriter(counter, key, data.IV)
+	if err != nil {
+		return 0, 0, err
+	}
+	compressed := &countingWriter{w: ew}
+	cw, err := NewCompressingWriter(compressed, data.Codec)
+	if err != nil {
+		return 0, 0, err
+	}
+	n, err := io.Copy(cw, r)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := cw.Close(); err != nil {
+		return 0, 0, err
+	}
+	if pad != nil {
+		padded, err := pad(compressed.n)
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, err := io.CopyN(ew, ZeroReader{}, padded-compressed.n); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := ew.Close(); err != nil {
+		return 0, 0, err
+	}
+	data.Size = counter.n
+	data.Hash = hash.Sum(nil)
+	return n, compressed.n, nil
+}
+
+// Decrypt returns reader of decrypted payload, if data are not attached to the executable, they are searched in storage.
+// Padding described by metadata is stripped.
+func Decrypt(data types.Data, key []byte, metadata types.Metadata, storage Storage) (io.ReadCloser, error) {
+	if data.Version < types.VersionStream {
+		// data built before streaming was introduced is stored inline
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(cipher.StreamReader{S: cipher.NewCFBDecrypter(block, data.IV), R: bytes.NewReader(data.Data)}), nil
+	}
+
+	r, err := openEncrypted(data, storage)
+	if err != nil {
+		return nil, err
+	}
+	dr, err := NewDecryptingReader(r, key, data.IV)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	compressed := dr
+	if metadata.Padded {
+		compressed = io.LimitReader(dr, metadata.Length)
+	}
+	dcr, err := NewDecompressingReader(compressed, data.Codec)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	// rest of the stream, padding especially, is read at the end, so all the chunks and hash are verified
+	return readCloser{Reader: drainingReader{r: dcr, rest: dr}, Closer: multiCloser{dcr, r}}, nil
+}
+
+// DataKey returns key used to encrypt data, for data built before data keys were introduced it is the private key itself
+func DataKey(data types.Data, key []byte) (*util.SecureBuffer, error) {
+	if data.WrappedKey == nil {
+		return util.SecureBufferFrom(append([]byte{}, key...)), nil
+	}
+	wrapKey := util.WrapKey(key)
+	defer wrapKey.Release()
+
+	dataKey, err := util.Open(wrapKey.Bytes(), data.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key failed: %w", err)
+	}
+	return dataKey, nil
+}
+
+// metadataBucket is the minimal size of sealed metadata, larger metadata are padded to the next power of two multiple of it
+const metadataBucket = 4096
+
+// SealMetadata encrypts metadata using key derived from key of payload. Metadata are padded with spaces, ignored by JSON
+// decoder, so length of ciphertext doesn't reveal number of files and length of their names.
+func SealMetadata(key []byte, metadata types.Metadata) ([]byte, error) {
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Zero(raw)
+
+	padded := metadataBucket
+	for padded < len(raw) {
+		padded *= 2
+	}
+	plaintext := util.NewSecureBuffer(padded)
+	defer plaintext.Release()
+	buf := plaintext.Bytes()
+	for i := copy(buf, raw); i < len(buf); i++ {
+		buf[i] = ' '
+	}
+
+	metadataKey := util.MetadataKey(key)
+	defer metadataKey.Release()
+
+	return util.Seal(metadataKey.Bytes(), plaintext.Bytes())
+}
+
+// OpenMetadata decrypts metadata of payload, false is returned if data were built before metadata were introduced
+func OpenMetadata(data types.Data, key []byte) (types.Metadata, bool, error) {
+	if data.Metadata == nil {
+		return types.Metadata{}, false, nil
+	}
+	metadataKey := util.MetadataKey(key)
+	defer metadataKey.Release()
+
+	raw, err := util.Open(metadataKey.Bytes(), data.Metadata)
+	if err != nil {
+		return types.Metadata{}, false, fmt.Errorf("decrypting metadata failed: %w", err)
+	}
+	defer raw.Release()
+
+	var metadata types.Metadata
+	if err := json.Unmarshal(raw.Bytes(), &metadata); err != nil {
+		return types.Metadata{}, false, err
+	}
+	return metadata, true, nil
+}
+
+// Check verifies that encrypted payload is available and its hash matches the one signed by the owner,
+// so successors don't gather their keys in vain. Shards are collected from successors during recovery
+// so they are verified when payload is decrypted.
+func Check(data types.Data, storage Storage) error {
+	if data.Version < types.VersionStream || data.ShardFiles != nil {
+		return nil
+	}
+	r, err := openEncrypted(data, storage)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return err
+	}
+	return r.Close()
+}
+
+func openEncrypted(data types.Data, storage Storage) (io.ReadCloser, error) {
+	switch {
+	case data.ShardFiles != nil:
+		return openSharded(data, storage)
+	case data.PayloadFile == "":
+		return OpenAttached(util.ExecutablePath(), data.Offset, data.Size, data.Hash)
+	default:
+		return OpenFile(storage.payloadFile(data), data.Offset, data.Size, data.Hash)
+	}
+}
+
+type multiCloser []io.Closer
+
+func (mc multiCloser) Close() error {
+	var retErr error
+	for _, c := range mc {
+		if err := c.Close(); retErr == nil {
+			retErr = err
+		}
+	}
+	return retErr
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// drainingReader reads rest once r is exhausted
+type drainingReader struct {
+	r    io.Reader
+	rest io.Reader
+}
+
+func (dr drainingReader) Read(p []byte) (int, error) {
+	n, err := dr.r.Read(p)
+	if err == io.EOF {
+		if _, err := io.Copy(ioutil.Discard, dr.rest); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
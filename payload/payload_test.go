@@ -0,0 +1,177 @@
+package payload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// encryptPayload encrypts plaintext into payload file and returns data and metadata describing it
+func encryptPayload(t *testing.T, plaintext []byte, codec types.Codec, pad PadFunc) (types.Data, types.Metadata, Storage) {
+	t.Helper()
+	data := types.Data{Name: "test", Version: types.VersionStream, Codec: codec, PayloadFile: "test.payload"}
+	buf := &bytes.Buffer{}
+	_, length, err := Encrypt(buf, bytes.NewReader(plaintext), testKey, &data, pad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Size != int64(buf.Len()) {
+		t.Fatalf("size of payload is %d but %d bytes were written", data.Size, buf.Len())
+	}
+	file := filepath.Join(t.TempDir(), data.PayloadFile)
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	metadata := types.Metadata{}
+	if pad != nil {
+		metadata.Padded = true
+		metadata.Length = length
+	}
+	return data, metadata, Storage{PayloadFile: file}
+}
+
+func decryptPayload(data types.Data, metadata types.Metadata, storage Storage) ([]byte, error) {
+	r, err := Decrypt(data, testKey, metadata, storage)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func TestPaddingStripped(t *testing.T) {
+	bucket := func(size int64) PadFunc {
+		return func(length int64) (int64, error) {
+			padded := size
+			for padded < length {
+				padded *= 2
+			}
+			return padded, nil
+		}
+	}
+	fixed := func(size int64) PadFunc {
+		return func(length int64) (int64, error) {
+			if length > size {
+				return 0, errors.New("padding size is too small")
+			}
+			return size, nil
+		}
+	}
+
+	tests := []struct {
+		name   string
+		size   int
+		codec  types.Codec
+		pad    PadFunc
+		padded int64
+	}{
+		{name: "no padding", size: 1000, codec: types.CodecNone, pad: nil, padded: 1000},
+		{name: "empty in bucket", size: 0, codec: types.CodecNone, pad: bucket(4096), padded: 4096},
+		{name: "bucket", size: 1000, codec: types.CodecNone, pad: bucket(4096), padded: 4096},
+		{name: "exactly bucket", size: 4096, codec: types.CodecNone, pad: bucket(4096), padded: 4096},
+		{name: "next bucket", size: 4097, codec: types.CodecNone, pad: bucket(4096), padded: 8192},
+		{name: "bucket spanning chunks", size: ChunkSize + 1, codec: types.CodecNone, pad: bucket(ChunkSize), padded: 2 * ChunkSize},
+		{name: "fixed size", size: 1000, codec: types.CodecNone, pad: fixed(3 * ChunkSize), padded: 3 * ChunkSize},
+		{name: "exactly fixed size", size: ChunkSize, codec: types.CodecNone, pad: fixed(ChunkSize), padded: ChunkSize},
+		{name: "compressed in bucket", size: 3 * ChunkSize, codec: types.CodecGzip, pad: bucket(4096), padded: -1},
+		{name: "compressed fixed size", size: 3 * ChunkSize, codec: types.CodecGzip, pad: fixed(ChunkSize), padded: ChunkSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := testPlaintext(tt.size)
+			data, metadata, storage := encryptPayload(t, plaintext, tt.codec, tt.pad)
+
+			chunks := int64(1)
+			if tt.padded > ChunkSize {
+				chunks = (tt.padded + ChunkSize - 1) / ChunkSize
+			}
+			if expected := tt.padded + chunks*(sealedChunkSize-ChunkSize); tt.padded >= 0 && data.Size != expected {
+				t.Fatalf("expected %d bytes of encrypted payload, got %d", expected, data.Size)
+			}
+
+			decrypted, err := decryptPayload(data, metadata, storage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("decrypted payload differs, %d bytes expected, got %d", len(plaintext), len(decrypted))
+			}
+		})
+	}
+}
+
+func TestPaddingTooSmall(t *testing.T) {
+	data := types.Data{Version: types.VersionStream, Codec: types.CodecNone}
+	pad := func(length int64) (int64, error) {
+		return 0, fmt.Errorf("payload has %d bytes", length)
+	}
+	if _, _, err := Encrypt(ioutil.Discard, bytes.NewReader(testPlaintext(100)), testKey, &data, pad); err == nil {
+		t.Fatal("payload larger than padding has been encrypted")
+	}
+}
+
+func TestPaddedPayloadTampered(t *testing.T) {
+	plaintext := testPlaintext(1000)
+	data, metadata, storage := encryptPayload(t, plaintext, types.CodecNone, func(int64) (int64, error) {
+		return 2 * ChunkSize, nil
+	})
+	content, err := ioutil.ReadFile(storage.PayloadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// padding is authenticated even though it is stripped
+	content[len(content)-20] ^= 0x01
+	if err := ioutil.WriteFile(storage.PayloadFile, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptPayload(data, metadata, storage); err == nil {
+		t.Fatal("payload with tampered padding has been decrypted")
+	}
+}
+
+func TestMetadataPadded(t *testing.T) {
+	metadata := func(files int) types.Metadata {
+		m := types.Metadata{Note: "note"}
+		for i := 0; i < files; i++ {
+			m.Manifest.Files = append(m.Manifest.Files, types.File{Path: fmt.Sprintf("dir/file-%d.txt", i), Size: int64(i)})
+		}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		files []int
+	}{
+		{name: "small", files: []int{0, 1, 10}},
+		{name: "large", files: []int{100, 101, 150}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := -1
+			for _, files := range tt.files {
+				sealed, err := SealMetadata(testKey, metadata(files))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if size >= 0 && len(sealed) != size {
+					t.Fatalf("sealed metadata of %d files have %d bytes, expected %d", files, len(sealed), size)
+				}
+				size = len(sealed)
+
+				opened, ok, err := OpenMetadata(types.Data{Metadata: sealed}, testKey)
+				if err != nil || !ok {
+					t.Fatalf("opening metadata failed: %v", err)
+				}
+				if len(opened.Manifest.Files) != files || opened.Note != "note" {
+					t.Fatal("opened metadata differ")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/reedsolomon"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// encrypted payload might be split into shards using Reed-Solomon code, one shard per successor,
+// any RequiredToDecrypt shards are enough to reconstruct it. Shards of all the payloads given to the successor
+// are concatenated into successor's shard file, shards of the payload are stored at the same offset in each of them.
+
+// MaxShards is the maximum number of shards payload might be split into
+const MaxShards = 256
+
+// WriteShards splits encrypted payload of size read from r into dataShards data shards followed by parity shards,
+// shard i is appended to files[i] at offset, size of each shard and their hashes are returned
+func WriteShards(r io.Reader, size int64, dataShards int, files []*os.File, offset int64) (int64, [][]byte, error) {
+	if dataShards < 1 || dataShards > len(files) || len(files) > MaxShards {
+		return 0, nil, fmt.Errorf("payload can't be split into %d shards with %d data shards", len(files), dataShards)
+	}
+	shardSize := (size + int64(dataShards) - 1) / int64(dataShards)
+	padded := io.MultiReader(io.LimitReader(r, size), ZeroReader{})
+	for _, f := range files[:dataShards] {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return 0, nil, err
+		}
+		if _, err := io.CopyN(f, padded, shardSize); err != nil {
+			return 0, nil, err
+		}
+	}
+	if len(files) > dataShards && shardSize > 0 {
+		enc, err := reedsolomon.NewStream(dataShards, len(files)-dataShards)
+		if err != nil {
+			return 0, nil, err
+		}
+		data := make([]io.Reader, 0, dataShards)
+		for _, f := range files[:dataShards] {
+			data = append(data, io.NewSectionReader(f, offset, shardSize))
+		}
+		parity := make([]io.Writer, 0, len(files)-dataShards)
+		for _, f := range files[dataShards:] {
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				return 0, nil, err
+			}
+			parity = append(parity, f)
+		}
+		if err := enc.Encode(data, parity); err != nil {
+			return 0, nil, fmt.Errorf("computing parity shards failed: %w", err)
+		}
+	}
+
+	hashes := make([][]byte, 0, len(files))
+	for _, f := range files {
+		h := sha256.New()
+		n, err := io.Copy(h, io.NewSectionReader(f, offset, shardSize))
+		if err != nil {
+			return 0, nil, err
+		}
+		if n != shardSize {
+			return 0, nil, errors.New("shard has not been written completely")
+		}
+		hashes = append(hashes, h.Sum(nil))
+	}
+	return shardSize, hashes, nil
+}
+
+// AvailableShards returns number of shards of payload found in shard files, their content is verified once they are read
+func AvailableShards(data types.Data, storage Storage) int {
+	count := 0
+	for i := range data.ShardFiles {
+		info, err := os.Stat(storage.shardFile(data, i))
+		if err == nil && fits(data.Offset, data.ShardSize, info.Size()) {
+			count++
+		}
+	}
+	return count
+}
+
+// MissingShardFiles returns paths of shard files which haven't been found
+func MissingShardFiles(data types.Data, storage Storage) []string {
+	var missing []string
+	for i := range data.ShardFiles {
+		file := storage.shardFile(data, i)
+		if _, err := os.Stat(file); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
+
+// openSharded reconstructs encrypted payload from shards, shards which can't be read or have invalid hash are ignored
+func openSharded(data types.Data, storage Storage) (io.ReadCloser, error) {
+	dataShards := data.RequiredToDecrypt
+	if len(data.ShardFiles) != len(data.ShardHashes) || dataShards < 1 || dataShards > len(data.ShardFiles) {
+		return nil, errors.New("sharding of payload is invalid")
+	}
+
+	closers := multiCloser{}
+	files := make([]*os.File, len(data.ShardFiles))
+	count := 0
+	for i := range data.ShardFiles {
+		f, err := openShard(data, storage, i)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Shard %d of payload %s ignored: %s\n", i, data.Name, err)
+			continue
+		}
+		closers = append(closers, f)
+		files[i] = f
+		count++
+	}
+	if count < dataShards {
+		_ = closers.Close()
+		return nil, fmt.Errorf("%d shard(s) of payload %s are available but %d are required", count, data.Name, dataShards)
+	}
+
+	missing := false
+	for _, f := range files[:dataShards] {
+		if f == nil {
+			missing = true
+		}
+	}
+	if missing {
+		c, err := reconstruct(data, files)
+		if err != nil {
+			_ = closers.Close()
+			return nil, err
+		}
+		closers = append(closers, c)
+	}
+
+	shards := make([]io.Reader, 0, dataShards)
+	for _, f := range files[:dataShards] {
+		shards = append(shards, io.NewSectionReader(f, data.Offset, data.ShardSize))
+	}
+	return readCloser{
+		Reader: NewVerifyingReader(io.LimitReader(io.MultiReader(shards...), data.Size), data.Hash),
+		Closer: closers,
+	}, nil
+}
+
+// openShard opens shard file if hash of the shard matches the one signed by the owner
+func openShard(data types.Data, storage Storage, i int) (*os.File, error) {
+	f, err := os.Open(storage.shardFile(data, i))
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !fits(data.Offset, data.ShardSize, info.Size()) {
+		f.Close()
+		return nil, errors.New("size of shard file is invalid")
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, data.Offset, data.ShardSize)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !bytes.Equal(h.Sum(nil), data.ShardHashes[i]) {
+		f.Close()
+		return nil, errors.New("hash of shard does not match the one signed by the owner")
+	}
+	return f, nil
+}
+
+// reconstruct recreates missing data shards in temporary files stored in files, at offset of the payload,
+// returned closer closes and removes them
+func reconstruct(data types.Data, files []*os.File) (io.Closer, error) {
+	dataShards := data.RequiredToDecrypt
+	dec, err := reedsolomon.NewStream(dataShards, len(files)-dataShards)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "legacy-shards-")
+	if err != nil {
+		return nil, err
+	}
+	closers := multiCloser{removeDir(tmpDir)}
+
+	valid := make([]io.Reader, len(files))
+	fill := make([]io.Writer, len(files))
+	for i, f := range files {
+		if f != nil {
+			valid[i] = io.NewSectionReader(f, data.Offset, data.ShardSize)
+			continue
+		}
+		if i >= dataShards {
+			continue
+		}
+		tmp, err := os.Create(filepath.Join(tmpDir, fmt.Sprintf("%d", i)))
+		if err != nil {
+			_ = closers.Close()
+			return nil, err
+		}
+		// closing file must precede removing the directory
+		closers = append(multiCloser{tmp}, closers...)
+		// reconstructed shard is written at offset of the payload, so it is read the same way as other shards
+		if _, err := tmp.Seek(data.Offset, io.SeekStart); err != nil {
+			_ = closers.Close()
+			return nil, err
+		}
+		fill[i] = tmp
+		files[i] = tmp
+	}
+	if err := dec.Reconstruct(valid, fill); err != nil {
+		_ = closers.Close()
+		return nil, fmt.Errorf("reconstructing payload %s from shards failed: %w", data.Name, err)
+	}
+	return closers, nil
+}
+
+// ZeroReader is the reader producing infinite stream of zeros
+type ZeroReader struct{}
+
+// Read fills p with zeros
+func (ZeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+type removeDir string
+
+func (rd removeDir) Close() error {
+	return os.RemoveAll(string(rd))
+}
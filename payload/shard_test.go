@@ -0,0 +1,178 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// shardOffset is the offset of tested payload in shard files, preceded by shards of other payloads
+const shardOffset = 123
+
+// writeTestShards splits payload into shard files stored in dir and returns data describing them
+func writeTestShards(t *testing.T, dir string, payload []byte, shards, required int) types.Data {
+	t.Helper()
+	files := make([]*os.File, 0, shards)
+	names := make([]string, 0, shards)
+	for i := 0; i < shards; i++ {
+		name := fmt.Sprintf("successor-%d.shards", i)
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.Write(bytes.Repeat([]byte{0xff}, shardOffset)); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+		names = append(names, name)
+	}
+	shardSize, hashes, err := WriteShards(bytes.NewReader(payload), int64(len(payload)), required, files, shardOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(payload)
+	return types.Data{
+		Name:              "test",
+		RequiredToDecrypt: required,
+		Offset:            shardOffset,
+		Size:              int64(len(payload)),
+		Hash:              hash[:],
+		ShardFiles:        names,
+		ShardSize:         shardSize,
+		ShardHashes:       hashes,
+	}
+}
+
+// collectShards copies shard files selected by mask to new directory
+func collectShards(t *testing.T, srcDir string, data types.Data, mask uint) string {
+	t.Helper()
+	dir := t.TempDir()
+	for i, name := range data.ShardFiles {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(srcDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readSharded(data types.Data, dir string) ([]byte, error) {
+	r, err := openSharded(data, Storage{ShardDir: dir})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func TestShardsReconstructedFromThreshold(t *testing.T) {
+	tests := []struct {
+		shards   int
+		required int
+		size     int
+	}{
+		{shards: 1, required: 1, size: 1000},
+		{shards: 2, required: 1, size: 1000},
+		{shards: 3, required: 2, size: 1},
+		{shards: 3, required: 2, size: 1001},
+		{shards: 3, required: 3, size: 999},
+		{shards: 5, required: 3, size: 3 * ChunkSize},
+		{shards: 6, required: 4, size: 4*ChunkSize + 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d of %d, %d bytes", tt.required, tt.shards, tt.size), func(t *testing.T) {
+			srcDir := t.TempDir()
+			payload := testPlaintext(tt.size)
+			data := writeTestShards(t, srcDir, payload, tt.shards, tt.required)
+			if expected := int64((tt.size + tt.required - 1) / tt.required); data.ShardSize != expected {
+				t.Fatalf("expected shard size %d, got %d", expected, data.ShardSize)
+			}
+
+			for mask := uint(0); mask < 1<<uint(tt.shards); mask++ {
+				dir := collectShards(t, srcDir, data, mask)
+				if n := AvailableShards(data, Storage{ShardDir: dir}); n != bits.OnesCount(mask) {
+					t.Fatalf("shards %b: %d shards reported as available", mask, n)
+				}
+				decoded, err := readSharded(data, dir)
+				if bits.OnesCount(mask) < tt.required {
+					if err == nil {
+						t.Fatalf("shards %b: payload reconstructed from %d shards", mask, bits.OnesCount(mask))
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("shards %b: %s", mask, err)
+				}
+				if !bytes.Equal(decoded, payload) {
+					t.Fatalf("shards %b: reconstructed payload differs", mask)
+				}
+			}
+		})
+	}
+}
+
+func TestCorruptedShardIgnored(t *testing.T) {
+	srcDir := t.TempDir()
+	payload := testPlaintext(10000)
+	data := writeTestShards(t, srcDir, payload, 4, 2)
+
+	// first data shard is corrupted, it is reconstructed from the remaining ones
+	dir := collectShards(t, srcDir, data, 0b1011)
+	file := filepath.Join(dir, data.ShardFiles[0])
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content[shardOffset+10] ^= 0x01
+	if err := ioutil.WriteFile(file, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := readSharded(data, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, payload) {
+		t.Fatal("reconstructed payload differs")
+	}
+
+	// with one more shard missing, there are not enough valid shards
+	if err := os.Remove(filepath.Join(dir, data.ShardFiles[3])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSharded(data, dir); err == nil {
+		t.Fatal("payload reconstructed from corrupted shard")
+	}
+}
+
+func TestWriteShardsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	files := make([]*os.File, 0, 2)
+	for i := 0; i < 2; i++ {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+	for _, required := range []int{0, 3} {
+		if _, _, err := WriteShards(bytes.NewReader([]byte("data")), 4, required, files, 0); err == nil {
+			t.Fatalf("payload split into %d shards with %d data shards", len(files), required)
+		}
+	}
+}
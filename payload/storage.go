@@ -0,0 +1,161 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+// encrypted payloads are concatenated into container appended to the executable and followed by trailer
+// containing its size and magic bytes
+
+var magic = []byte("LEGACYPL")
+
+const trailerSize = 16
+
+// Attach appends container of payloads stored in file to the executable
+func Attach(exeFile, payloadFile string) (retErr error) {
+	payload, err := os.Open(payloadFile)
+	if err != nil {
+		return err
+	}
+	defer payload.Close()
+
+	exe, err := os.OpenFile(exeFile, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := exe.Close(); retErr == nil {
+			retErr = err
+		}
+	}()
+
+	size, err := io.Copy(exe, payload)
+	if err != nil {
+		return err
+	}
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint64(trailer, uint64(size))
+	copy(trailer[8:], magic)
+	_, err = exe.Write(trailer)
+	return err
+}
+
+// Storage defines where encrypted payloads are searched if they are not attached to the executable
+type Storage struct {
+	// PayloadFile is the path of the file storing encrypted payloads, by default it is searched next to the executable
+	PayloadFile string
+
+	// ShardDir is the directory where shard files of successors are searched, by default it is the directory of the executable
+	ShardDir string
+}
+
+func (s Storage) payloadFile(data types.Data) string {
+	if s.PayloadFile != "" {
+		return s.PayloadFile
+	}
+	return filepath.Join(filepath.Dir(util.ExecutablePath()), data.PayloadFile)
+}
+
+func (s Storage) shardFile(data types.Data, i int) string {
+	dir := s.ShardDir
+	if dir == "" {
+		dir = filepath.Dir(util.ExecutablePath())
+	}
+	return filepath.Join(dir, data.ShardFiles[i])
+}
+
+// OpenAttached opens payload stored at offset of the container attached to the executable,
+// hash of the payload is verified while it is read
+func OpenAttached(exeFile string, offset, size int64, hash []byte) (io.ReadCloser, error) {
+	exe, err := os.Open(exeFile)
+	if err != nil {
+		return nil, err
+	}
+	r, err := attached(exe, offset, size, hash)
+	if err != nil {
+		exe.Close()
+		return nil, err
+	}
+	return readCloser{Reader: r, Closer: exe}, nil
+}
+
+func attached(exe *os.File, offset, size int64, hash []byte) (io.Reader, error) {
+	info, err := exe.Stat()
+	if err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, trailerSize)
+	if info.Size() < trailerSize {
+		return nil, errors.New("payload is not attached to the executable")
+	}
+	if _, err := exe.ReadAt(trailer, info.Size()-trailerSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(trailer[8:], magic) {
+		return nil, errors.New("payload is not attached to the executable")
+	}
+	containerSize := int64(binary.LittleEndian.Uint64(trailer))
+	if containerSize < 0 || info.Size()-trailerSize < containerSize || !fits(offset, size, containerSize) {
+		return nil, errors.New("size of payload attached to the executable is invalid")
+	}
+	return NewVerifyingReader(io.NewSectionReader(exe, info.Size()-trailerSize-containerSize+offset, size), hash), nil
+}
+
+// OpenFile opens payload stored at offset of external file, hash of the payload is verified while it is read
+func OpenFile(file string, offset, size int64, hash []byte) (io.ReadCloser, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !fits(offset, size, info.Size()) {
+		f.Close()
+		return nil, fmt.Errorf("size of payload file %s is invalid", file)
+	}
+	return readCloser{Reader: NewVerifyingReader(io.NewSectionReader(f, offset, size), hash), Closer: f}, nil
+}
+
+// fits returns true if payload of size stored at offset fits in container
+func fits(offset, size, containerSize int64) bool {
+	return offset >= 0 && size >= 0 && offset <= containerSize && size <= containerSize-offset
+}
+
+// NewVerifyingReader returns reader computing hash of data and returning error at the end if it doesn't match the expected one
+func NewVerifyingReader(r io.Reader, expectedHash []byte) io.Reader {
+	return &verifyingReader{r: r, hash: sha256.New(), expectedHash: expectedHash}
+}
+
+type verifyingReader struct {
+	r            io.Reader
+	hash         hash.Hash
+	expectedHash []byte
+}
+
+func (vr *verifyingReader) Read(p []byte) (int, error) {
+	n, err := vr.r.Read(p)
+	_, _ = vr.hash.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(vr.hash.Sum(nil), vr.expectedHash) {
+		return n, errors.New("hash of payload does not match the one signed by the owner")
+	}
+	return n, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,175 @@
+package payload
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ChunkSize is the size of plaintext chunk encrypted and authenticated separately
+const ChunkSize = 64 * 1024
+
+// NoncePrefixSize is the size of random prefix of nonces used to encrypt chunks
+const NoncePrefixSize = 7
+
+// nonce of each chunk is built from random prefix, counter of chunks and flag set for the last chunk only,
+// so reordering, removing and truncating chunks are detected
+
+func newAEAD(key, noncePrefix []byte) (cipher.AEAD, error) {
+	if len(noncePrefix) != NoncePrefixSize {
+		return nil, errors.New("invalid size of nonce prefix")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(nonce []byte, noncePrefix []byte, counter uint32, last bool) []byte {
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[NoncePrefixSize:], counter)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// NewEncryptingWriter returns writer encrypting data in chunks, Close must be called to write the last chunk
+func NewEncryptingWriter(w io.Writer, key, noncePrefix []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key, noncePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptingWriter{
+		w:           w,
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		nonce:       make([]byte, aead.NonceSize()),
+		buf:         make([]byte, 0, ChunkSize),
+		sealed:      make([]byte, 0, ChunkSize+aead.Overhead()),
+	}, nil
+}
+
+type encryptingWriter struct {
+	w           io.Writer
+	aead        cipher.AEAD
+	noncePrefix []byte
+	nonce       []byte
+	counter     uint32
+	buf         []byte
+	sealed      []byte
+	closed      bool
+}
+
+func (ew *encryptingWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, errors.New("writer is closed")
+	}
+	n := 0
+	for len(p) > 0 {
+		// full chunk is sealed only when more data comes, because the last chunk has to be marked
+		if len(ew.buf) == ChunkSize {
+			if err := ew.seal(false); err != nil {
+				return n, err
+			}
+		}
+		copied := copy(ew.buf[len(ew.buf):ChunkSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+copied]
+		p = p[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+func (ew *encryptingWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+	return ew.seal(true)
+}
+
+func (ew *encryptingWriter) seal(last bool) error {
+	if ew.counter == ^uint32(0) {
+		return errors.New("too many chunks")
+	}
+	ew.sealed = ew.aead.Seal(ew.sealed[:0], chunkNonce(ew.nonce, ew.noncePrefix, ew.counter, last), ew.buf, nil)
+	ew.counter++
+	ew.buf = ew.buf[:0]
+	_, err := ew.w.Write(ew.sealed)
+	return err
+}
+
+// NewDecryptingReader returns reader decrypting data encrypted by writer returned by NewEncryptingWriter
+func NewDecryptingReader(r io.Reader, key, noncePrefix []byte) (io.Reader, error) {
+	aead, err := newAEAD(key, noncePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReader{
+		r:           bufio.NewReaderSize(r, ChunkSize+aead.Overhead()),
+		aead:        aead,
+		noncePrefix: noncePrefix,
+		nonce:       make([]byte, aead.NonceSize()),
+		sealed:      make([]byte, ChunkSize+aead.Overhead()),
+		plain:       make([]byte, 0, ChunkSize),
+	}, nil
+}
+
+type decryptingReader struct {
+	r           *bufio.Reader
+	aead        cipher.AEAD
+	noncePrefix []byte
+	nonce       []byte
+	counter     uint32
+	sealed      []byte
+	plain       []byte
+	buf         []byte
+	last        bool
+}
+
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.last {
+			return 0, io.EOF
+		}
+		if err := dr.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
+
+func (dr *decryptingReader) open() error {
+	n, err := io.ReadFull(dr.r, dr.sealed)
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		dr.last = true
+	case err != nil:
+		return err
+	default:
+		// chunk is the last one if there is no more data
+		if _, err := dr.r.Peek(1); err == io.EOF {
+			dr.last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if dr.counter == ^uint32(0) {
+		return errors.New("too many chunks")
+	}
+	buf, err := dr.aead.Open(dr.plain[:0], chunkNonce(dr.nonce, dr.noncePrefix, dr.counter, dr.last), dr.sealed[:n], nil)
+	if err != nil {
+		return errors.New("payload is corrupted or truncated")
+	}
+	dr.counter++
+	dr.buf = buf
+	return nil
+}
@@ -0,0 +1,163 @@
+package payload
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// sealedChunkSize is the size of encrypted full chunk, including GCM tag
+const sealedChunkSize = ChunkSize + 16
+
+var (
+	testKey         = bytes.Repeat([]byte{0x42}, 32)
+	testNoncePrefix = []byte{1, 2, 3, 4, 5, 6, 7}
+)
+
+func testPlaintext(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func encryptStream(t *testing.T, plaintext []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	ew, err := NewEncryptingWriter(buf, testKey, testNoncePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ew.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(ciphertext []byte) ([]byte, error) {
+	dr, err := NewDecryptingReader(bytes.NewReader(ciphertext), testKey, testNoncePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(dr)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		chunks int
+	}{
+		{name: "empty", size: 0, chunks: 1},
+		{name: "one byte", size: 1, chunks: 1},
+		{name: "chunk minus one", size: ChunkSize - 1, chunks: 1},
+		{name: "exactly chunk", size: ChunkSize, chunks: 1},
+		{name: "chunk plus one", size: ChunkSize + 1, chunks: 2},
+		{name: "exactly three chunks", size: 3 * ChunkSize, chunks: 3},
+		{name: "three chunks and a half", size: 3*ChunkSize + ChunkSize/2, chunks: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := testPlaintext(tt.size)
+			ciphertext := encryptStream(t, plaintext)
+			if expected := tt.size + tt.chunks*(sealedChunkSize-ChunkSize); len(ciphertext) != expected {
+				t.Fatalf("expected %d bytes of ciphertext, got %d", expected, len(ciphertext))
+			}
+			decrypted, err := decryptStream(ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatal("decrypted data differ")
+			}
+		})
+	}
+}
+
+func TestStreamWrittenInPieces(t *testing.T) {
+	plaintext := testPlaintext(2*ChunkSize + 100)
+	buf := &bytes.Buffer{}
+	ew, err := NewEncryptingWriter(buf, testKey, testNoncePrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rest := plaintext; len(rest) > 0; {
+		n := 1000
+		if n > len(rest) {
+			n = len(rest)
+		}
+		if _, err := ew.Write(rest[:n]); err != nil {
+			t.Fatal(err)
+		}
+		rest = rest[n:]
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), encryptStream(t, plaintext)) {
+		t.Fatal("ciphertext depends on sizes of writes")
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	// three full chunks and the last short one
+	plaintext := testPlaintext(3*ChunkSize + 10)
+	ciphertext := encryptStream(t, plaintext)
+	chunk := func(i int) []byte {
+		end := (i + 1) * sealedChunkSize
+		if end > len(ciphertext) {
+			end = len(ciphertext)
+		}
+		return ciphertext[i*sealedChunkSize : end]
+	}
+	join := func(chunks ...[]byte) []byte {
+		return bytes.Join(chunks, nil)
+	}
+
+	tests := []struct {
+		name     string
+		tampered []byte
+	}{
+		{name: "empty", tampered: nil},
+		{name: "truncated at chunk boundary", tampered: ciphertext[:3*sealedChunkSize]},
+		{name: "truncated after first chunk", tampered: ciphertext[:sealedChunkSize]},
+		{name: "truncated inside chunk", tampered: ciphertext[:sealedChunkSize+100]},
+		{name: "last byte removed", tampered: ciphertext[:len(ciphertext)-1]},
+		{name: "first chunk removed", tampered: ciphertext[sealedChunkSize:]},
+		{name: "middle chunk removed", tampered: join(chunk(0), chunk(2), chunk(3))},
+		{name: "chunks reordered", tampered: join(chunk(1), chunk(0), chunk(2), chunk(3))},
+		{name: "chunk duplicated", tampered: join(chunk(0), chunk(0), chunk(1), chunk(2), chunk(3))},
+		{name: "data appended", tampered: join(ciphertext, []byte{0})},
+		{name: "stream appended", tampered: join(ciphertext, ciphertext)},
+		{name: "bit flipped", tampered: func() []byte {
+			tampered := append([]byte{}, ciphertext...)
+			tampered[sealedChunkSize+5] ^= 0x01
+			return tampered
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decryptStream(tt.tampered); err == nil {
+				t.Fatal("tampered stream has been decrypted")
+			}
+		})
+	}
+}
+
+func TestStreamWrongNoncePrefix(t *testing.T) {
+	ciphertext := encryptStream(t, testPlaintext(10))
+	dr, err := NewDecryptingReader(bytes.NewReader(ciphertext), testKey, []byte{7, 6, 5, 4, 3, 2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(dr); err == nil {
+		t.Fatal("stream has been decrypted using wrong nonce prefix")
+	}
+	if _, err := NewDecryptingReader(bytes.NewReader(ciphertext), testKey, []byte{1}); err == nil {
+		t.Fatal("invalid nonce prefix has been accepted")
+	}
+}
@@ -0,0 +1,32 @@
+package payload
+
+import "github.com/wojciech-malota-wojcik/legacy/types"
+
+// Versions returns all the versions of payload, the newest first, each one describes the payload with encrypted stream of the version
+func Versions(data types.Data) []types.Data {
+	versions := make([]types.Data, 0, len(data.History)+1)
+	latest := data
+	latest.History = nil
+	versions = append(versions, latest)
+	for _, version := range data.History {
+		versions = append(versions, WithStream(data, version))
+	}
+	return versions
+}
+
+// WithStream returns payload data with encrypted stream and metadata taken from version
+func WithStream(data, version types.Data) types.Data {
+	data.History = nil
+	data.Kind = version.Kind
+	data.Codec = version.Codec
+	data.IV = version.IV
+	data.Offset = version.Offset
+	data.Size = version.Size
+	data.Hash = version.Hash
+	data.PayloadFile = version.PayloadFile
+	data.ShardFiles = version.ShardFiles
+	data.ShardSize = version.ShardSize
+	data.ShardHashes = version.ShardHashes
+	data.Metadata = version.Metadata
+	return data
+}
@@ -1,13 +1,63 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+// Version is the version of data format
+type Version int
+
+const (
+	// VersionSeedSalt is the version where salt used to build private key is sampled from seed
+	VersionSeedSalt Version = iota
+
+	// VersionRandomSalt is the version where random salt used to build private key is stored with data
+	VersionRandomSalt
+
+	// VersionStream is the version where data are encrypted in authenticated chunks and attached to the executable
+	VersionStream
+)
+
+// Kind is the kind of data
+type Kind int
+
+const (
+	// KindFile means data are the content of single file
+	KindFile Kind = iota
+
+	// KindArchive means data are tar archive of directory tree
+	KindArchive
+
+	// KindDocument means data are legacy document encoded as JSON
+	KindDocument
+)
+
+// Codec is the compression codec applied to data before encryption
+type Codec int
+
+const (
+	// CodecNone means data are not compressed
+	CodecNone Codec = iota
+
+	// CodecGzip means data are compressed using gzip
+	CodecGzip
+)
 
 // Successor contains all the data required to decrypt a part
 type Successor struct {
-	PublicKey []byte
-	Key       []byte
-	IV        []byte
-	Part      []byte
+	Name           string
+	PublicKey      []byte
+	PassphraseSalt []byte
+	Key            []byte
+	IV             []byte
+	Part           []byte
+	Letter         []byte
+}
+
+// IsPassphrase returns true if successor uses passphrase instead of YubiKey
+func (s Successor) IsPassphrase() bool {
+	return s.PassphraseSalt != nil
 }
 
 // String returns string representation of data
@@ -15,10 +65,35 @@ func (s Successor) String() string {
 	return fmt.Sprintf("%#v", s)
 }
 
-// Data represent data
+// Data represent data, Data field stores encrypted data inline for versions older than VersionStream,
+// otherwise encrypted data of specified size and hash are stored at offset of the container attached to the executable
+// or stored in PayloadFile next to it. If WrappedKey is set, data are encrypted using random data key wrapped by private key.
+// RequiredToDecrypt successors, including all the RequiredSuccessors, are needed to decrypt data.
+// If ShardFiles are set, encrypted data are split into shards of ShardSize stored at offset of shard file of each successor,
+// any RequiredToDecrypt shards are enough to reconstruct them.
+// History contains earlier versions of data, the newest first, encrypted using the same data key,
+// only fields describing encrypted stream and metadata are set in them.
 type Data struct {
-	IV   []byte
-	Data []byte
+	Name               string
+	Version            Version
+	Kind               Kind
+	Codec              Codec
+	RequiredToDecrypt  int
+	RequiredSuccessors []int
+	Salt               []byte
+	KeyCheck           []byte
+	WrappedKey         []byte
+	IV                 []byte
+	Offset             int64
+	Size               int64
+	Hash               []byte
+	PayloadFile        string
+	ShardFiles         []string
+	ShardSize          int64
+	ShardHashes        [][]byte
+	Metadata           []byte
+	History            []Data
+	Data               []byte
 }
 
 // String returns string representation of data
@@ -26,6 +101,86 @@ func (d Data) String() string {
 	return fmt.Sprintf("%#v", d)
 }
 
+// Metadata contains information about payload, it is stored in Data.Metadata encrypted using key of payload
+type Metadata struct {
+	Manifest Manifest
+
+	// Padded is set if encrypted stream is padded with zeros, then Length is the length of compressed data preceding padding
+	Padded bool  `json:",omitempty"`
+	Length int64 `json:",omitempty"`
+
+	// Time is the time when version of data was created and Note is the optional description given by the owner
+	Time time.Time
+	Note string `json:",omitempty"`
+}
+
+// Manifest describes plaintext of payload, for archives regular files are listed
+type Manifest struct {
+	Size  int64
+	Hash  []byte
+	Files []File `json:",omitempty"`
+}
+
+// File describes regular file stored in archive
+type File struct {
+	Path string
+	Size int64
+	Hash []byte
+}
+
+// Document is the structured legacy document, entries are grouped in categories and might have files attached
+type Document struct {
+	Title        string
+	Instructions string     `json:",omitempty"`
+	Categories   []Category `json:",omitempty"`
+	Entries      []Entry    `json:",omitempty"`
+}
+
+// Category groups entries of document
+type Category struct {
+	Name        string
+	Description string `json:",omitempty"`
+}
+
+// Entry describes single item of document, like account, contact or credentials
+type Entry struct {
+	Title       string
+	Category    string       `json:",omitempty"`
+	Fields      []Field      `json:",omitempty"`
+	Notes       string       `json:",omitempty"`
+	Attachments []Attachment `json:",omitempty"`
+}
+
+// Field is named value of entry, secret values are hidden by the viewer until revealed
+type Field struct {
+	Name   string
+	Value  string
+	Secret bool `json:",omitempty"`
+}
+
+// Attachment is the file attached to entry
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Owner contains public key of the owner and signature of data and successors
+type Owner struct {
+	PublicKey []byte
+	Signature []byte
+}
+
+// String returns string representation of owner
+func (o Owner) String() string {
+	return fmt.Sprintf("%#v", o)
+}
+
+// Part is the content of successor's part, seed trees and shares of required successors are indexed by payload
+type Part struct {
+	Trees  map[int]SeedNode `json:"t,omitempty"`
+	Shares map[int][]byte   `json:"s,omitempty"`
+}
+
 // SeedNode is a node of seed tree
 type SeedNode struct {
 	Data []byte           `json:"d,omitempty"`
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// labelCheckpoint separates key used to encrypt checkpoints from other hashes
+var labelCheckpoint = []byte("legacy/checkpoint")
+
+// Checkpoint stores encrypted intermediate state of key generation so it might be resumed after interruption
+type Checkpoint interface {
+	// Load returns stored checkpoint or nil if there is no checkpoint
+	Load() ([]byte, error)
+
+	// Store stores checkpoint
+	Store(checkpoint []byte) error
+
+	// Remove removes checkpoint
+	Remove() error
+}
+
+// NewFileCheckpoint returns checkpoint stored in file
+func NewFileCheckpoint(file string) Checkpoint {
+	return fileCheckpoint{file: file}
+}
+
+type fileCheckpoint struct {
+	file string
+}
+
+func (c fileCheckpoint) Load() ([]byte, error) {
+	checkpoint, err := ioutil.ReadFile(c.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return checkpoint, err
+}
+
+func (c fileCheckpoint) Store(checkpoint []byte) error {
+	if err := os.MkdirAll(filepath.Dir(c.file), 0o700); err != nil {
+		return err
+	}
+	// file is replaced atomically so valid checkpoint exists even if process is killed while storing the new one
+	tmpFile := c.file + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, checkpoint, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, c.file)
+}
+
+func (c fileCheckpoint) Remove() error {
+	if err := os.Remove(c.file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// checkpointCipher returns cipher used to encrypt checkpoints, key is derived from seed so checkpoint is useless without it
+func checkpointCipher(seed, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, seed)
+	_, _ = mac.Write(labelCheckpoint)
+	_, _ = mac.Write(salt)
+	key := SecureBufferFrom(mac.Sum(nil))
+	defer key.Release()
+
+	block, err := aes.NewCipher(key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// storeCheckpoint encrypts and stores number of completed steps together with salt and state reached after them
+func storeCheckpoint(checkpoint Checkpoint, aead cipher.AEAD, step int, salt, state []byte) error {
+	plain := NewSecureBuffer(8 + len(salt) + len(state))
+	defer plain.Release()
+
+	binary.LittleEndian.PutUint64(plain.Bytes(), uint64(step))
+	copy(plain.Bytes()[8:], salt)
+	copy(plain.Bytes()[8+len(salt):], state)
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+aead.Overhead()+len(plain.Bytes()))
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return checkpoint.Store(aead.Seal(nonce, nonce, plain.Bytes(), labelCheckpoint))
+}
+
+// loadCheckpoint loads and decrypts checkpoint, salt and state are overwritten by the stored ones and number of completed steps is returned,
+// 0 is returned if there is no checkpoint or if it was not created for this seed
+func loadCheckpoint(checkpoint Checkpoint, aead cipher.AEAD, salt, state []byte) (int, error) {
+	sealed, err := checkpoint.Load()
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return 0, err
+	}
+
+	plain := NewSecureBuffer(len(sealed))
+	defer plain.Release()
+
+	nonce := sealed[:aead.NonceSize()]
+	opened, err := aead.Open(plain.Bytes()[:0], nonce, sealed[aead.NonceSize():], labelCheckpoint)
+	if err != nil || len(opened) != 8+len(salt)+len(state) {
+		return 0, nil
+	}
+	copy(salt, opened[8:])
+	copy(state, opened[8+len(salt):])
+	return int(binary.LittleEndian.Uint64(opened)), nil
+}
@@ -7,9 +7,14 @@ import (
 	"github.com/ridge/must"
 )
 
+// ExecutablePath returns path of the running executable with symlinks resolved
+func ExecutablePath() string {
+	return must.String(filepath.EvalSymlinks(must.String(os.Executable())))
+}
+
 // WorkingDir sets working directory by going up the tree by specified number of steps from the directory where executable exists
 func WorkingDir(steps int) {
-	exePath := must.String(filepath.EvalSymlinks(must.String(os.Executable())))
+	exePath := ExecutablePath()
 	steps += 1
 	for i := 0; i < steps; i++ {
 		exePath = filepath.Dir(exePath)
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"sync"
+)
+
+// labelEntropy separates key of deterministic entropy stream from other hashes
+var labelEntropy = []byte("legacy/entropy")
+
+// entropy is the source of random bytes used to generate keys, salts and nonces of the legacy
+var entropy = &entropySource{r: rand.Reader}
+
+type entropySource struct {
+	mu            sync.Mutex
+	r             io.Reader
+	deterministic bool
+}
+
+// Random fills b with random bytes, they are deterministic if entropy snapshot is used
+func Random(b []byte) error {
+	entropy.mu.Lock()
+	defer entropy.mu.Unlock()
+
+	_, err := io.ReadFull(entropy.r, b)
+	return err
+}
+
+// UseEntropySnapshot replaces system randomness by AES-CTR stream keyed by hash of snapshot and digest of build inputs,
+// so builds from the same snapshot and inputs generate the same bytes, while any change of inputs changes all of them
+func UseEntropySnapshot(snapshot, inputs []byte) error {
+	h := sha256.New()
+	_, _ = h.Write(labelEntropy)
+	_, _ = h.Write(snapshot)
+	_, _ = h.Write(inputs)
+	key := h.Sum(nil)
+	defer Zero(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	entropy.mu.Lock()
+	defer entropy.mu.Unlock()
+
+	entropy.r = streamReader{stream: cipher.NewCTR(block, make([]byte, aes.BlockSize))}
+	entropy.deterministic = true
+	return nil
+}
+
+// DeterministicEntropy returns true if random bytes are generated from entropy snapshot
+func DeterministicEntropy() bool {
+	entropy.mu.Lock()
+	defer entropy.mu.Unlock()
+
+	return entropy.deterministic
+}
+
+type streamReader struct {
+	stream cipher.Stream
+}
+
+func (sr streamReader) Read(p []byte) (int, error) {
+	Zero(p)
+	sr.stream.XORKeyStream(p, p)
+	return len(p), nil
+}
@@ -1,17 +1,142 @@
 package util
 
 import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
+	"io"
 
 	"github.com/wojciech-malota-wojcik/legacy/config"
+	"github.com/wojciech-malota-wojcik/legacy/types"
 	"golang.org/x/crypto/argon2"
 )
 
-// BuildPrivateKey builds private key from seed
-func BuildPrivateKey(seed []byte) []byte {
-	preSalt := make([]byte, 8)
-	salt := []byte{
+// labels used to separate domains of hashes computed while building private key
+var (
+	labelSaltChain = []byte("legacy/salt-chain")
+	labelSeedChain = []byte("legacy/seed-chain")
+	labelKey       = []byte("legacy/key")
+	labelKeyCheck  = []byte("legacy/key-check")
+	labelMetadata  = []byte("legacy/metadata")
+	labelLetter    = []byte("legacy/letter")
+	labelWrap      = []byte("legacy/wrap")
+	labelLineage   = []byte("legacy/lineage")
+)
+
+// ProgressFunc is called by BuildPrivateKey each time next step of key generation is completed
+type ProgressFunc func(step, total int)
+
+// KeyProgress returns ProgressFunc printing percentage of key generation to w each time it changes, purpose is
+// the kind of the key printed, like "Encryption" or "Decryption"
+func KeyProgress(w io.Writer, purpose string) ProgressFunc {
+	progress := -1
+	return func(step, total int) {
+		newProgress := 100 * step / total
+		if newProgress != progress {
+			progress = newProgress
+			fmt.Fprintf(w, "%s key generation progress: %d%%\n", purpose, progress)
+		}
+	}
+}
+
+// BuildPrivateKey builds private key from seed, salt is ignored for data in VersionSeedSalt.
+// If checkpoint is not nil, state is stored there after each step and generation is resumed from it if possible.
+func BuildPrivateKey(ctx context.Context, seed, salt []byte, version types.Version, progress ProgressFunc, checkpoint Checkpoint) (*SecureBuffer, error) {
+	if version == types.VersionSeedSalt {
+		salt = seedSalt(seed)
+	}
+	salt = append([]byte{}, salt...)
+	state := NewSecureBuffer(len(seed))
+	defer state.Release()
+	copy(state.Bytes(), seed)
+
+	var aead cipher.AEAD
+	start := 0
+	if checkpoint != nil {
+		var err error
+		aead, err = checkpointCipher(seed, salt)
+		if err != nil {
+			return nil, err
+		}
+		start, err = loadCheckpoint(checkpoint, aead, salt, state.Bytes())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	progress(start, config.SeedToKeySteps)
+	for i := start; i < config.SeedToKeySteps; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		salt = argon2.Key(salt, stepSalt(version, i), 2, 16*1024, 1, uint32(len(salt)))
+		next := argon2.Key(state.Bytes(), withLabel(version, labelSeedChain, salt), 3, 64*1024, 3, uint32(len(seed)))
+		copy(state.Bytes(), next)
+		Zero(next)
+		if checkpoint != nil {
+			if err := storeCheckpoint(checkpoint, aead, i+1, salt, state.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+		progress(i+1, config.SeedToKeySteps)
+	}
+	key := SecureBufferFrom(argon2.Key(state.Bytes(), keySalt(version, salt), 5, 128*1024, 4, config.AESKeySize))
+	if checkpoint != nil {
+		if err := checkpoint.Remove(); err != nil {
+			key.Release()
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// KeyCheck computes value committing to the private key, it is stored with data to verify reconstructed key
+func KeyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(labelKeyCheck)
+	return mac.Sum(nil)
+}
+
+// MetadataKey derives key encrypting metadata of payload from the private key
+func MetadataKey(key []byte) *SecureBuffer {
+	return subKey(key, labelMetadata)
+}
+
+// LetterKey derives key encrypting personal letter of successor from the part key
+func LetterKey(partKey []byte) *SecureBuffer {
+	return subKey(partKey, labelLetter)
+}
+
+// WrapKey derives key encrypting data key of payload from the private key
+func WrapKey(key []byte) *SecureBuffer {
+	return subKey(key, labelWrap)
+}
+
+// LineageKey derives key encrypting lineage file from seed of the owner key
+func LineageKey(ownerSeed []byte) *SecureBuffer {
+	return subKey(ownerSeed, labelLineage)
+}
+
+func subKey(key, label []byte) *SecureBuffer {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(label)
+	return SecureBufferFrom(mac.Sum(nil))
+}
+
+// VerifyKey checks if private key matches the key check value, data built before key check was introduced is not verified
+func VerifyKey(key, keyCheck []byte) bool {
+	if keyCheck == nil {
+		return true
+	}
+	return hmac.Equal(KeyCheck(key), keyCheck)
+}
+
+// seedSalt samples salt from bytes of seed, used by data in VersionSeedSalt only
+func seedSalt(seed []byte) []byte {
+	return []byte{
 		seed[0],
 		seed[len(seed)-1],
 		seed[len(seed)/2],
@@ -21,17 +146,26 @@ func BuildPrivateKey(seed []byte) []byte {
 		seed[3*len(seed)/4],
 		seed[3*len(seed)/5],
 	}
-	progress := 0
-	fmt.Printf("Decryption key generation progress: %d%%\n", progress)
-	for i := 0; i < config.SeedToKeySteps; i++ {
-		binary.LittleEndian.PutUint64(preSalt, uint64(i))
-		salt = argon2.Key(salt, preSalt, 2, 16*1024, 1, uint32(len(salt)))
-		seed = argon2.Key(seed, salt, 3, 64*1024, 3, uint32(len(seed)))
-		newProgress := 100 * (i + 1) / config.SeedToKeySteps
-		if newProgress != progress {
-			progress = newProgress
-			fmt.Printf("Decryption key generation progress: %d%%\n", progress)
-		}
+}
+
+func stepSalt(version types.Version, step int) []byte {
+	preSalt := make([]byte, 8)
+	binary.LittleEndian.PutUint64(preSalt, uint64(step))
+	return withLabel(version, labelSaltChain, preSalt)
+}
+
+func keySalt(version types.Version, salt []byte) []byte {
+	if version == types.VersionSeedSalt {
+		return []byte("some very very random bytes for salt")
+	}
+	return withLabel(version, labelKey, salt)
+}
+
+func withLabel(version types.Version, label []byte, data []byte) []byte {
+	if version == types.VersionSeedSalt {
+		return data
 	}
-	return argon2.Key(seed, []byte("some very very random bytes for salt"), 5, 128*1024, 4, config.AESKeySize)
+	res := make([]byte, 0, len(label)+len(data))
+	res = append(res, label...)
+	return append(res, data...)
 }
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ridge/must"
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// labelManifest separates digest of manifest from other hashes
+var labelManifest = []byte("legacy/manifest")
+
+// Manifest computes digest of payloads and successors signed by the owner
+func Manifest(payloads []types.Data, successors []types.Successor) []byte {
+	h := sha256.New()
+	_, _ = h.Write(labelManifest)
+	e := json.NewEncoder(h)
+	for _, data := range payloads {
+		must.OK(e.Encode(data))
+	}
+	for _, s := range successors {
+		must.OK(e.Encode(s))
+	}
+	return h.Sum(nil)
+}
+
+// labelBuildManifest separates digest of build manifest from other hashes
+var labelBuildManifest = []byte("legacy/build-manifest")
+
+// BuildManifestDigest computes digest of encoded build manifest signed by the owner
+func BuildManifestDigest(manifest []byte) []byte {
+	h := sha256.New()
+	_, _ = h.Write(labelBuildManifest)
+	_, _ = h.Write(manifest)
+	return h.Sum(nil)
+}
+
+// VerifyOwner verifies that payloads and successors were signed by the owner
+func VerifyOwner(owner types.Owner, payloads []types.Data, successors []types.Successor) error {
+	if len(owner.PublicKey) != ed25519.PublicKeySize {
+		return errors.New("public key of the owner is invalid")
+	}
+	if !ed25519.Verify(owner.PublicKey, Manifest(payloads, successors), owner.Signature) {
+		return errors.New("signature of the owner is invalid, executable has been altered")
+	}
+	return nil
+}
+
+// Fingerprint returns fingerprint of public key
+func Fingerprint(pubKey []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(pubKey))
+}
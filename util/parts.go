@@ -0,0 +1,108 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// parts generated by the build are embedded in the executable as blobs, each blob is made of magic header,
+// SHA-256 hash of the body and the body being JSON encoding of the value, so values decoded from it are encoded
+// to exactly the same manifest as the one signed by the owner
+
+// blobMagic identifies blob of legacy parts
+var blobMagic = []byte("LEGACY\x00\x01")
+
+// EncodeBlob encodes value into blob embedded in the executable
+func EncodeBlob(v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(body)
+	blob := make([]byte, 0, len(blobMagic)+len(hash)+len(body))
+	blob = append(blob, blobMagic...)
+	blob = append(blob, hash[:]...)
+	return append(blob, body...), nil
+}
+
+// DecodeBlob verifies integrity of blob and decodes value from it
+func DecodeBlob(blob []byte, v interface{}) error {
+	if len(blob) < len(blobMagic)+sha256.Size || !bytes.Equal(blob[:len(blobMagic)], blobMagic) {
+		return errors.New("blob is not a part of legacy")
+	}
+	hash := blob[len(blobMagic) : len(blobMagic)+sha256.Size]
+	body := blob[len(blobMagic)+sha256.Size:]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], hash) {
+		return errors.New("blob is corrupted")
+	}
+	return json.Unmarshal(body, v)
+}
+
+// LoadParts decodes and validates owner, payloads and successors embedded in the executable
+func LoadParts(ownerBlob, payloadsBlob, successorsBlob []byte) (types.Owner, []types.Data, []types.Successor, error) {
+	var owner types.Owner
+	if err := DecodeBlob(ownerBlob, &owner); err != nil {
+		return types.Owner{}, nil, nil, fmt.Errorf("decoding owner failed: %w", err)
+	}
+	var payloads []types.Data
+	if err := DecodeBlob(payloadsBlob, &payloads); err != nil {
+		return types.Owner{}, nil, nil, fmt.Errorf("decoding payloads failed: %w", err)
+	}
+	var successors []types.Successor
+	if err := DecodeBlob(successorsBlob, &successors); err != nil {
+		return types.Owner{}, nil, nil, fmt.Errorf("decoding successors failed: %w", err)
+	}
+	if err := ValidateParts(owner, payloads, successors); err != nil {
+		return types.Owner{}, nil, nil, err
+	}
+	return owner, payloads, successors, nil
+}
+
+// ValidateParts verifies that payloads and successors are consistent, authenticity is verified by VerifyOwner
+func ValidateParts(owner types.Owner, payloads []types.Data, successors []types.Successor) error {
+	if len(owner.PublicKey) != ed25519.PublicKeySize || len(owner.Signature) != ed25519.SignatureSize {
+		return errors.New("owner is invalid")
+	}
+	if len(payloads) == 0 {
+		return errors.New("legacy contains no payloads")
+	}
+	if len(successors) == 0 {
+		return errors.New("legacy contains no successors")
+	}
+	for i, s := range successors {
+		if len(s.Part) == 0 || len(s.IV) == 0 || len(s.Key) == 0 {
+			return fmt.Errorf("part of successor %d is missing", i)
+		}
+		if len(s.IV) != aes.BlockSize {
+			return fmt.Errorf("IV of successor %d has invalid size", i)
+		}
+		if (s.PublicKey == nil) == (s.PassphraseSalt == nil) {
+			return fmt.Errorf("successor %d must use either YubiKey or passphrase", i)
+		}
+	}
+	names := map[string]bool{}
+	for _, data := range payloads {
+		// payload name is used as a file name, so it can't point outside of the output directory
+		if data.Name == "" || data.Name == "." || data.Name == ".." || strings.ContainsAny(data.Name, `/\`) || names[data.Name] {
+			return fmt.Errorf("name of payload %q is invalid or duplicated", data.Name)
+		}
+		names[data.Name] = true
+		if data.RequiredToDecrypt < 1 || data.RequiredToDecrypt > len(successors) {
+			return fmt.Errorf("payload %s requires %d successors but legacy contains %d", data.Name, data.RequiredToDecrypt, len(successors))
+		}
+		for _, r := range data.RequiredSuccessors {
+			if r < 0 || r >= len(successors) {
+				return fmt.Errorf("payload %s requires successor %d which does not exist", data.Name, r)
+			}
+		}
+	}
+	return nil
+}
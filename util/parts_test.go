@@ -0,0 +1,183 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"reflect"
+	"testing"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+func testParts(t *testing.T) (types.Owner, []types.Data, []types.Successor) {
+	t.Helper()
+	payloads := []types.Data{
+		{Name: "letter", Version: types.VersionStream, RequiredToDecrypt: 1, Salt: []byte{1, 2, 3}, Size: 100, Hash: []byte{4, 5, 6}},
+		{Name: "photos", Version: types.VersionStream, RequiredToDecrypt: 2, RequiredSuccessors: []int{1}, ShardFiles: []string{"a", "b"}},
+	}
+	successors := []types.Successor{
+		{Name: "A", PublicKey: []byte{7}, Part: []byte{8}, IV: bytes.Repeat([]byte{9}, aes.BlockSize), Key: []byte{10}},
+		{Name: "B", PassphraseSalt: []byte{11}, Part: []byte{12}, IV: bytes.Repeat([]byte{13}, aes.BlockSize), Key: []byte{14}},
+	}
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := types.Owner{PublicKey: pubKey, Signature: ed25519.Sign(privKey, Manifest(payloads, successors))}
+	return owner, payloads, successors
+}
+
+func encodeBlob(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	blob, err := EncodeBlob(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestBlobRoundTrip(t *testing.T) {
+	owner, payloads, successors := testParts(t)
+
+	loadedOwner, loadedPayloads, loadedSuccessors, err := LoadParts(encodeBlob(t, owner), encodeBlob(t, payloads), encodeBlob(t, successors))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loadedOwner, owner) || !reflect.DeepEqual(loadedPayloads, payloads) || !reflect.DeepEqual(loadedSuccessors, successors) {
+		t.Fatal("decoded parts differ")
+	}
+	if err := VerifyOwner(loadedOwner, loadedPayloads, loadedSuccessors); err != nil {
+		t.Fatalf("signature of decoded parts is invalid: %s", err)
+	}
+}
+
+func TestBlobTampered(t *testing.T) {
+	_, payloads, _ := testParts(t)
+	blob := encodeBlob(t, payloads)
+	headerSize := len(blobMagic) + 32
+
+	tamper := func(i int) []byte {
+		tampered := append([]byte{}, blob...)
+		tampered[i] ^= 0x01
+		return tampered
+	}
+
+	tests := []struct {
+		name     string
+		tampered []byte
+	}{
+		{name: "empty", tampered: nil},
+		{name: "magic only", tampered: blob[:len(blobMagic)]},
+		{name: "truncated header", tampered: blob[:headerSize-1]},
+		{name: "truncated body", tampered: blob[:len(blob)-1]},
+		{name: "body appended", tampered: append(append([]byte{}, blob...), ' ')},
+		{name: "magic changed", tampered: tamper(0)},
+		{name: "version changed", tampered: tamper(len(blobMagic) - 1)},
+		{name: "hash changed", tampered: tamper(len(blobMagic) + 5)},
+		{name: "first byte of body changed", tampered: tamper(headerSize)},
+		{name: "last byte of body changed", tampered: tamper(len(blob) - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded []types.Data
+			if err := DecodeBlob(tt.tampered, &decoded); err == nil {
+				t.Fatal("tampered blob has been decoded")
+			}
+		})
+	}
+}
+
+func TestPartsSignatureVerified(t *testing.T) {
+	owner, payloads, successors := testParts(t)
+
+	tests := []struct {
+		name   string
+		modify func(owner *types.Owner, payloads []types.Data, successors []types.Successor)
+	}{
+		{name: "payload renamed", modify: func(_ *types.Owner, payloads []types.Data, _ []types.Successor) {
+			payloads[0].Name = "will"
+		}},
+		{name: "policy changed", modify: func(_ *types.Owner, payloads []types.Data, _ []types.Successor) {
+			payloads[1].RequiredToDecrypt = 1
+		}},
+		{name: "part replaced", modify: func(_ *types.Owner, _ []types.Data, successors []types.Successor) {
+			successors[0].Part = []byte{0}
+		}},
+		{name: "signature changed", modify: func(owner *types.Owner, _ []types.Data, _ []types.Successor) {
+			owner.Signature = append([]byte{}, owner.Signature...)
+			owner.Signature[0] ^= 0x01
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := owner
+			p := append([]types.Data{}, payloads...)
+			s := append([]types.Successor{}, successors...)
+			tt.modify(&o, p, s)
+
+			// blobs are valid, because their hashes are recomputed, but signature of the owner is not
+			loadedOwner, loadedPayloads, loadedSuccessors, err := LoadParts(encodeBlob(t, o), encodeBlob(t, p), encodeBlob(t, s))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyOwner(loadedOwner, loadedPayloads, loadedSuccessors); err == nil {
+				t.Fatal("signature of modified parts is valid")
+			}
+		})
+	}
+}
+
+func TestValidateParts(t *testing.T) {
+	owner, payloads, successors := testParts(t)
+	if err := ValidateParts(owner, payloads, successors); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(payloads []types.Data, successors []types.Successor)
+	}{
+		{name: "IV too short", modify: func(_ []types.Data, successors []types.Successor) {
+			successors[0].IV = successors[0].IV[:aes.BlockSize-1]
+		}},
+		{name: "IV too long", modify: func(_ []types.Data, successors []types.Successor) {
+			successors[1].IV = append(append([]byte{}, successors[1].IV...), 0)
+		}},
+		{name: "payload name empty", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[0].Name = ""
+		}},
+		{name: "payload name duplicated", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[1].Name = payloads[0].Name
+		}},
+		{name: "payload name is current directory", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[0].Name = "."
+		}},
+		{name: "payload name is parent directory", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[0].Name = ".."
+		}},
+		{name: "payload name contains slash", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[0].Name = "../letter"
+		}},
+		{name: "payload name contains backslash", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[0].Name = `..\letter`
+		}},
+		{name: "too many successors required", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[0].RequiredToDecrypt = 3
+		}},
+		{name: "required successor missing", modify: func(payloads []types.Data, _ []types.Successor) {
+			payloads[1].RequiredSuccessors = []int{2}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := append([]types.Data{}, payloads...)
+			s := append([]types.Successor{}, successors...)
+			tt.modify(p, s)
+			if err := ValidateParts(owner, p, s); err == nil {
+				t.Fatal("invalid parts are valid")
+			}
+		})
+	}
+}
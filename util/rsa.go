@@ -0,0 +1,48 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+)
+
+// EncryptPKCS1v15 encrypts msg using public key and PKCS #1 v1.5 padding. If entropy snapshot is used padding is generated
+// from it, standard library can't be used then because it consumes random bytes nondeterministically or ignores
+// the reader passed to it.
+func EncryptPKCS1v15(pub *rsa.PublicKey, msg []byte) ([]byte, error) {
+	if !DeterministicEntropy() {
+		return rsa.EncryptPKCS1v15(rand.Reader, pub, msg)
+	}
+
+	k := pub.Size()
+	if len(msg) > k-11 {
+		return nil, rsa.ErrMessageTooLong
+	}
+
+	// EM = 0x00 || 0x02 || PS || 0x00 || M, where PS consists of nonzero random bytes
+	em := make([]byte, k)
+	defer Zero(em)
+
+	em[1] = 2
+	ps := em[2 : k-len(msg)-1]
+	if err := Random(ps); err != nil {
+		return nil, err
+	}
+	for i := range ps {
+		for ps[i] == 0 {
+			if err := Random(ps[i : i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	copy(em[k-len(msg):], msg)
+
+	m := new(big.Int).SetBytes(em)
+	defer m.SetInt64(0)
+	if m.Cmp(pub.N) >= 0 {
+		return nil, errors.New("message representative is out of range")
+	}
+	c := new(big.Int).Exp(m, big.NewInt(int64(pub.E)), pub.N)
+	return c.FillBytes(make([]byte, k)), nil
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// useTestEntropy makes random bytes deterministic until the test finishes
+func useTestEntropy(t *testing.T) {
+	entropy.mu.Lock()
+	r, deterministic := entropy.r, entropy.deterministic
+	entropy.mu.Unlock()
+	t.Cleanup(func() {
+		entropy.mu.Lock()
+		defer entropy.mu.Unlock()
+		entropy.r, entropy.deterministic = r, deterministic
+	})
+
+	if err := UseEntropySnapshot(bytes.Repeat([]byte{0x01}, 64), []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptPKCS1v15Deterministic(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := key.Size()
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "one byte", size: 1},
+		{name: "AES key", size: 32},
+		{name: "maximum", size: k - 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestEntropy(t)
+			msg := bytes.Repeat([]byte{0xa5}, tt.size)
+
+			ciphertext, err := EncryptPKCS1v15(&key.PublicKey, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !DeterministicEntropy() {
+				t.Fatal("entropy is not deterministic")
+			}
+
+			decrypted, err := rsa.DecryptPKCS1v15(rand.Reader, key, ciphertext)
+			if err != nil {
+				t.Fatalf("standard library can't decrypt: %s", err)
+			}
+			if !bytes.Equal(decrypted, msg) {
+				t.Fatal("decrypted message differs")
+			}
+
+			// EM = 0x00 || 0x02 || PS || 0x00 || M
+			em := new(big.Int).Exp(new(big.Int).SetBytes(ciphertext), key.D, key.N).FillBytes(make([]byte, k))
+			if em[0] != 0 || em[1] != 2 || em[k-tt.size-1] != 0 {
+				t.Fatalf("encoded message has invalid structure: %x", em[:2])
+			}
+			ps := em[2 : k-tt.size-1]
+			if len(ps) < 8 {
+				t.Fatalf("padding has %d bytes, at least 8 are required", len(ps))
+			}
+			if i := bytes.IndexByte(ps, 0); i >= 0 {
+				t.Fatalf("padding contains zero byte at %d", i)
+			}
+		})
+	}
+}
+
+func TestEncryptPKCS1v15Repeatable(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("part key")
+
+	var ciphertexts [2][]byte
+	for i := range ciphertexts {
+		t.Run("", func(t *testing.T) {
+			useTestEntropy(t)
+			ciphertexts[i], err = EncryptPKCS1v15(&key.PublicKey, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if !bytes.Equal(ciphertexts[0], ciphertexts[1]) {
+		t.Fatal("ciphertexts generated from the same entropy snapshot differ")
+	}
+}
+
+func TestEncryptPKCS1v15TooLong(t *testing.T) {
+	useTestEntropy(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = EncryptPKCS1v15(&key.PublicKey, make([]byte, key.Size()-10))
+	if !errors.Is(err, rsa.ErrMessageTooLong) {
+		t.Fatalf("expected %s, got %v", rsa.ErrMessageTooLong, err)
+	}
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/wojciech-malota-wojcik/legacy/config"
+	"golang.org/x/crypto/argon2"
+)
+
+// labels used to separate domains of hashes computed by sealing functions
+var (
+	labelPassphrase = []byte("legacy/passphrase")
+	labelSealNonce  = []byte("legacy/seal-nonce")
+)
+
+// Seal encrypts and authenticates data using AES-GCM, nonce is prepended to the result. Nonce is derived from key
+// and data, so it is never reused for different data, even if random bytes are repeated by entropy snapshot.
+func Seal(key, data []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(labelSealNonce)
+	_, _ = mac.Write(data)
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	copy(nonce, mac.Sum(nil))
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// Open decrypts and authenticates data encrypted by Seal, result is stored in secure buffer
+func Open(key, sealed []byte) (*SecureBuffer, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("sealed data is too short")
+	}
+	data := NewSecureBuffer(len(sealed) - aead.NonceSize() - aead.Overhead())
+	if _, err := aead.Open(data.Bytes()[:0], sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil); err != nil {
+		data.Release()
+		return nil, err
+	}
+	return data, nil
+}
+
+// PassphraseKey derives key from passphrase using strong parameters of argon2
+func PassphraseKey(passphrase, salt []byte) *SecureBuffer {
+	labeledSalt := append(append([]byte{}, labelPassphrase...), salt...)
+	return SecureBufferFrom(argon2.IDKey(passphrase, labeledSalt, 8, 256*1024, 4, config.AESKeySize))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,126 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+)
+
+// maxSecretSize is the maximum size of secret read from standard input
+const maxSecretSize = 1024
+
+// SecureBuffer stores secret outside of memory managed by garbage collector, on supported platforms
+// it is locked in memory, so it is never swapped to disk, and excluded from core dumps. Memory is zeroed on release.
+type SecureBuffer struct {
+	mem  []byte
+	data []byte
+}
+
+// NewSecureBuffer allocates secure buffer of specified size
+func NewSecureBuffer(size int) *SecureBuffer {
+	mem := allocSecure(size)
+	return &SecureBuffer{mem: mem, data: mem[:size]}
+}
+
+// SecureBufferFrom copies data to new secure buffer and zeroes the source
+func SecureBufferFrom(data []byte) *SecureBuffer {
+	b := NewSecureBuffer(len(data))
+	copy(b.data, data)
+	Zero(data)
+	return b
+}
+
+// Bytes returns content of the buffer, it must not be used after buffer is released
+func (b *SecureBuffer) Bytes() []byte {
+	return b.data
+}
+
+// UnsafeString returns string sharing memory with the buffer, it must not be used after buffer is released
+func (b *SecureBuffer) UnsafeString() string {
+	if len(b.data) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b.data))
+}
+
+// Release zeroes and frees memory of the buffer
+func (b *SecureBuffer) Release() {
+	if b == nil || b.mem == nil {
+		return
+	}
+	freeSecure(b.mem)
+	b.mem = nil
+	b.data = nil
+}
+
+// Zero overwrites data with zeros
+func Zero(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+// ZeroString overwrites content of string with zeros, it might be used only for strings allocated at runtime,
+// like those decoded from JSON, because string literals are stored in read-only memory. Single-byte strings are
+// skipped because runtime shares them between all the conversions.
+func ZeroString(s string) {
+	if len(s) <= 1 {
+		return
+	}
+	Zero(*(*[]byte)(unsafe.Pointer(&struct {
+		string
+		cap int
+	}{s, len(s)})))
+}
+
+// ReadSecret reads line from standard input directly into secure buffer
+func ReadSecret() (*SecureBuffer, error) {
+	b := NewSecureBuffer(maxSecretSize)
+	n := 0
+	for {
+		if n == len(b.mem) {
+			b.Release()
+			return nil, errors.New("secret is too long")
+		}
+		_, err := os.Stdin.Read(b.mem[n : n+1])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			b.Release()
+			return nil, err
+		}
+		if b.mem[n] == '\n' {
+			b.mem[n] = 0
+			break
+		}
+		n++
+	}
+	if n > 0 && b.mem[n-1] == '\r' {
+		b.mem[n-1] = 0
+		n--
+	}
+	b.data = b.mem[:n]
+	return b, nil
+}
+
+// ZeroSeedTree overwrites all the data stored in seed tree with zeros
+func ZeroSeedTree(node *types.SeedNode) {
+	Zero(node.Data)
+	for _, sN := range node.Sub {
+		ZeroSeedTree(&sN)
+	}
+}
+
+// ZeroPart overwrites all the seed trees and shares stored in part with zeros
+func ZeroPart(part *types.Part) {
+	for _, tree := range part.Trees {
+		ZeroSeedTree(&tree)
+	}
+	for _, share := range part.Shares {
+		Zero(share)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build linux
+// +build linux
+
+package util
+
+import (
+	"os"
+
+	"github.com/ridge/must"
+	"golang.org/x/sys/unix"
+)
+
+func allocSecure(size int) []byte {
+	pageSize := os.Getpagesize()
+	mem, err := unix.Mmap(-1, 0, (size/pageSize+1)*pageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	must.OK(err)
+
+	// locking fails if limit of locked memory is too low, memory is still zeroed on release then
+	_ = unix.Mlock(mem)
+	_ = unix.Madvise(mem, unix.MADV_DONTDUMP)
+	return mem
+}
+
+func freeSecure(mem []byte) {
+	Zero(mem)
+	_ = unix.Munlock(mem)
+	must.OK(unix.Munmap(mem))
+}
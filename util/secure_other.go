@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package util
+
+func allocSecure(size int) []byte {
+	return make([]byte, size)
+}
+
+func freeSecure(mem []byte) {
+	Zero(mem)
+}
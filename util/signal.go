@@ -0,0 +1,25 @@
+package util
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalContext returns context canceled when process receives interrupt or termination signal,
+// after that signals are not captured anymore so the next one terminates the process immediately
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigCh:
+		case <-ctx.Done():
+		}
+		signal.Stop(sigCh)
+		cancel()
+	}()
+	return ctx, cancel
+}
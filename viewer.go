@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wojciech-malota-wojcik/legacy/types"
+	"github.com/wojciech-malota-wojcik/legacy/util"
+)
+
+const viewerHelp = `Commands:
+  i              show instructions
+  c              list categories
+  l [category]   list entries, optionally only those in category
+  / <text>       search entries, secret values are not searched
+  <n>            show entry n, secret values are hidden
+  r <n>          show entry n with secret values revealed
+  a <n> <m>      save attachment m of entry n to the output directory
+  h              show this help
+  q              close document`
+
+// document is the decrypted document, secret values and attachments are moved to secure buffers
+type document struct {
+	types.Document
+
+	// secrets are indexed by entry and field, they are nil for values which are not secret
+	secrets [][]*util.SecureBuffer
+
+	// attachments are indexed by entry and attachment
+	attachments [][]*util.SecureBuffer
+}
+
+// newDocument moves secret values and content of attachments of decoded document to secure buffers
+// and overwrites their decoded copies with zeros
+func newDocument(doc types.Document) *document {
+	d := &document{
+		Document:    doc,
+		secrets:     make([][]*util.SecureBuffer, len(doc.Entries)),
+		attachments: make([][]*util.SecureBuffer, len(doc.Entries)),
+	}
+	for i, e := range doc.Entries {
+		d.secrets[i] = make([]*util.SecureBuffer, len(e.Fields))
+		for j, f := range e.Fields {
+			if !f.Secret {
+				continue
+			}
+			d.secrets[i][j] = util.SecureBufferFrom([]byte(f.Value))
+			util.ZeroString(f.Value)
+			e.Fields[j].Value = ""
+		}
+		d.attachments[i] = make([]*util.SecureBuffer, len(e.Attachments))
+		for j, a := range e.Attachments {
+			d.attachments[i][j] = util.SecureBufferFrom(a.Data)
+			e.Attachments[j].Data = nil
+		}
+	}
+	return d
+}
+
+// release zeroes secret values and attachments
+func (d *document) release() {
+	for i := range d.Entries {
+		for _, b := range d.secrets[i] {
+			b.Release()
+		}
+		for _, b := range d.attachments[i] {
+			b.Release()
+		}
+	}
+}
+
+// view shows document in terminal until successor closes it, nothing is stored unless attachment is saved explicitly
+func view(name string, doc *document, output outputs) error {
+	fmt.Fprintf(os.Stderr, "Document %s: %s\n", name, doc.Title)
+	if doc.Instructions != "" {
+		fmt.Fprintf(os.Stderr, "\n%s\n\n", strings.TrimSpace(doc.Instructions))
+	}
+	fmt.Fprintln(os.Stderr, viewerHelp)
+	for {
+		fmt.Fprintf(os.Stderr, "%s> ", name)
+		line, err := readline()
+		if err != nil {
+			return nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch cmd := fields[0]; {
+		case cmd == "q":
+			return nil
+		case cmd == "h":
+			fmt.Fprintln(os.Stderr, viewerHelp)
+		case cmd == "i":
+			if doc.Instructions == "" {
+				fmt.Fprintln(os.Stderr, "Document has no instructions")
+				continue
+			}
+			fmt.Fprintln(os.Stderr, strings.TrimSpace(doc.Instructions))
+		case cmd == "c":
+			listCategories(doc)
+		case cmd == "l":
+			category := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "l"))
+			listEntries(doc, func(e types.Entry) bool {
+				return category == "" || strings.EqualFold(e.Category, category)
+			})
+		case strings.HasPrefix(cmd, "/"):
+			text := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "/")))
+			if text == "" {
+				fmt.Fprintln(os.Stderr, "Text to search is empty")
+				continue
+			}
+			listEntries(doc, func(e types.Entry) bool {
+				return matches(e, text)
+			})
+		case cmd == "r" && len(fields) == 2:
+			if i, ok := entry(doc, fields[1]); ok {
+				showEntry(doc, i, true)
+			}
+		case cmd == "a" && len(fields) == 3:
+			if i, ok := entry(doc, fields[1]); ok {
+				if err := saveAttachment(doc, i, fields[2], output); err != nil {
+					fmt.Fprintf(os.Stderr, "Saving attachment failed: %s\n", err)
+				}
+			}
+		case len(fields) == 1:
+			if i, ok := entry(doc, fields[0]); ok {
+				showEntry(doc, i, false)
+			}
+		default:
+			fmt.Fprintln(os.Stderr, "Unknown command, type h to show help")
+		}
+	}
+}
+
+func listCategories(doc *document) {
+	if len(doc.Categories) == 0 {
+		fmt.Fprintln(os.Stderr, "Document has no categories")
+		return
+	}
+	for _, c := range doc.Categories {
+		count := 0
+		for _, e := range doc.Entries {
+			if e.Category == c.Name {
+				count++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  %s (%d entries)", c.Name, count)
+		if c.Description != "" {
+			fmt.Fprintf(os.Stderr, ": %s", c.Description)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func listEntries(doc *document, filter func(e types.Entry) bool) {
+	found := 0
+	for i, e := range doc.Entries {
+		if !filter(e) {
+			continue
+		}
+		found++
+		fmt.Fprintf(os.Stderr, "%4d  %s", i+1, e.Title)
+		if e.Category != "" {
+			fmt.Fprintf(os.Stderr, " [%s]", e.Category)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+	if found == 0 {
+		fmt.Fprintln(os.Stderr, "No entries found")
+	}
+}
+
+// matches returns true if lowercase text is found in entry, secret values are skipped so they are never hinted
+func matches(e types.Entry, text string) bool {
+	candidates := []string{e.Title, e.Category, e.Notes}
+	for _, f := range e.Fields {
+		candidates = append(candidates, f.Name)
+		if !f.Secret {
+			candidates = append(candidates, f.Value)
+		}
+	}
+	for _, a := range e.Attachments {
+		candidates = append(candidates, a.Name)
+	}
+	for _, c := range candidates {
+		if strings.Contains(strings.ToLower(c), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// entry returns index of entry by its number printed by the viewer
+func entry(doc *document, number string) (int, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(doc.Entries) {
+		fmt.Fprintln(os.Stderr, "Invalid entry number")
+		return 0, false
+	}
+	return n - 1, true
+}
+
+func showEntry(doc *document, i int, reveal bool) {
+	e := doc.Entries[i]
+	fmt.Fprintln(os.Stderr, e.Title)
+	if e.Category != "" {
+		fmt.Fprintf(os.Stderr, "  Category: %s\n", e.Category)
+	}
+	for j, f := range e.Fields {
+		value := f.Value
+		switch {
+		case f.Secret && !reveal:
+			value = "******** (type r <n> to reveal)"
+		case f.Secret:
+			value = doc.secrets[i][j].UnsafeString()
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", f.Name, value)
+	}
+	if e.Notes != "" {
+		fmt.Fprintln(os.Stderr, "  Notes:")
+		for _, l := range strings.Split(e.Notes, "\n") {
+			fmt.Fprintf(os.Stderr, "    %s\n", l)
+		}
+	}
+	if len(e.Attachments) > 0 {
+		fmt.Fprintln(os.Stderr, "  Attachments:")
+		for j, a := range e.Attachments {
+			fmt.Fprintf(os.Stderr, "    %d) %s, %d bytes\n", j+1, a.Name, len(doc.attachments[i][j].Bytes()))
+		}
+	}
+}
+
+// saveAttachment stores attachment of entry in the output directory, named after the attachment
+func saveAttachment(doc *document, i int, number string, output outputs) error {
+	e := doc.Entries[i]
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(e.Attachments) {
+		return fmt.Errorf("entry %q has no attachment %s", e.Title, number)
+	}
+	a := e.Attachments[n-1]
+	dir := output.dir
+	if dir == "" {
+		dir = "."
+	}
+	path := filepath.Join(dir, a.Name)
+	if err := output.checkPath(path); err != nil {
+		return err
+	}
+	tmp, err := writeFile(bytes.NewReader(doc.attachments[i][n-1].Bytes()), path, output.mode)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	if err := output.commit(tmp, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Attachment %s saved to %s\n", a.Name, path)
+	return nil
+}